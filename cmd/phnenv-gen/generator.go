@@ -0,0 +1,518 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/phonaputer/phnenv"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	phnEnvStructTag = "phnenv"
+
+	// These messages must match the errors returned by phnenv.Parse.
+	errMsgNumericOverflow = "environment value overflows numeric type"
+	errMsgRuneLength      = "less/more than 1 rune found for rune type"
+	errMsgConflictingKeys = "aliased environment variables are set to different values"
+	errMsgSecretInvalid   = "invalid value for secret field, not shown"
+)
+
+var (
+	errTypeNotFound      = errors.New("type not found")
+	errNotStruct         = errors.New("type is not a struct")
+	errUnsupportedType   = errors.New("field type is not supported by phnenv-gen")
+	errUnsupportedOption = errors.New("tag option is not supported by phnenv-gen")
+	errUnexportedField   = errors.New("unexported fields can't be set by phnenv.Parse")
+)
+
+// specialTypes are the types which phnenv.Parse handles as a single value, but which phnenv-gen does not support.
+var specialTypes = map[string]bool{
+	"net.IP":                                true,
+	"net.IPNet":                             true,
+	"net/netip.Addr":                        true,
+	"net/netip.AddrPort":                    true,
+	"net/netip.Prefix":                      true,
+	"net/url.URL":                           true,
+	"github.com/phonaputer/phnenv.HostPort": true,
+}
+
+// generate returns the formatted source of a file containing a parse function for each of the named struct types
+// in the package in dir. The file named outFile is excluded when type checking the package, because it may
+// contain previously generated code which no longer compiles.
+func generate(dir string, typeNames []string, outFile string) ([]byte, error) {
+	pkg, err := loadPackage(dir, outFile)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, imports: map[string]string{"fmt": "fmt"}}
+
+	for _, name := range typeNames {
+		err := g.genParseFunc(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return g.source()
+}
+
+func loadPackage(dir string, outFile string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		if name == outFile {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+
+	return conf.Check(bp.ImportPath, fset, files, nil)
+}
+
+// generator accumulates the generated parse functions and the imports they need.
+type generator struct {
+	pkg     *types.Package
+	body    bytes.Buffer
+	imports map[string]string // import path to package name
+
+	// tmp is used to give unique names to temporary variables.
+	tmp int
+}
+
+func (g *generator) genParseFunc(typeName string) error {
+	obj, ok := g.pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return errTypeNotFound
+	}
+
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return errNotStruct
+	}
+
+	funcName := parseFuncName(typeName)
+
+	g.printf("// %s fills v with config values returned by lookup, in the same way as phnenv.Parse\n", funcName)
+	g.printf("// called without any Options. To read the OS environment, pass os.LookupEnv as lookup.\n")
+	g.printf("func %s(v *%s, lookup func(string) (string, bool)) error {\n", funcName, typeName)
+
+	err := g.genStruct(st, "v", nil)
+	if err != nil {
+		return err
+	}
+
+	g.printf("return nil\n}\n\n")
+
+	return nil
+}
+
+func parseFuncName(typeName string) string {
+	r, size := utf8.DecodeRuneInString(typeName)
+	if unicode.IsUpper(r) {
+		return "Parse" + typeName
+	}
+
+	return "parse" + string(unicode.ToUpper(r)) + typeName[size:]
+}
+
+func (g *generator) genStruct(st *types.Struct, target string, path []string) error {
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		fieldPath := append(append([]string{}, path...), f.Name())
+
+		err := g.genField(f, st.Tag(i), target+"."+f.Name(), fieldPath)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) genField(f *types.Var, tag string, target string, path []string) error {
+	if nested, ok := structType(f.Type()); ok {
+		if !hasTags(nested, map[*types.Struct]bool{}) {
+			return nil // structs without any phnenv tags, such as time.Time or sync.Mutex, are skipped by phnenv.Parse
+		}
+		if !f.Exported() {
+			return errUnexportedField
+		}
+
+		return g.genNestedStruct(f.Type(), nested, target, path)
+	}
+
+	tagStr, ok := reflect.StructTag(tag).Lookup(phnEnvStructTag)
+	if !ok {
+		return nil
+	}
+
+	if !f.Exported() {
+		return errUnexportedField
+	}
+
+	t, err := phnenv.ParseTag(tagStr)
+	if err != nil {
+		return err
+	}
+
+	err = checkSupportedOptions(t)
+	if err != nil {
+		return err
+	}
+
+	g.genLookup(t.Keys, path)
+
+	err = g.genValue(target, f.Type(), t, "conf", errFmt(path, "%w"))
+	if err != nil {
+		return err
+	}
+
+	g.printf("}\n")
+
+	return nil
+}
+
+// genNestedStruct allocates any nil pointers leading to a nested struct, then fills the struct's fields.
+func (g *generator) genNestedStruct(typ types.Type, st *types.Struct, target string, path []string) error {
+	for {
+		ptr, ok := typ.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+
+		g.printf("if %s == nil {\n%s = new(%s)\n}\n", target, target, g.typeString(ptr.Elem()))
+		target = "(*" + target + ")"
+		typ = ptr.Elem()
+	}
+
+	return g.genStruct(st, target, path)
+}
+
+func checkSupportedOptions(t phnenv.Tag) error {
+	switch {
+	case t.ByteSize:
+		return fmt.Errorf("%w: bytesize", errUnsupportedOption)
+	case t.Absolute:
+		return fmt.Errorf("%w: absolute", errUnsupportedOption)
+	case len(t.Schemes) > 0:
+		return fmt.Errorf("%w: schemes:", errUnsupportedOption)
+	case t.Required:
+		return fmt.Errorf("%w: required", errUnsupportedOption)
+	case t.Unset:
+		return fmt.Errorf("%w: unset", errUnsupportedOption)
+	case t.Credential != "":
+		return fmt.Errorf("%w: credential:", errUnsupportedOption)
+	}
+
+	return nil
+}
+
+// genLookup opens a block in which the variable conf holds the config for the first of keys which is set.
+func (g *generator) genLookup(keys []string, path []string) {
+	if len(keys) == 1 {
+		g.printf("if conf, ok := lookup(%q); ok {\n", keys[0])
+		return
+	}
+
+	g.printf("if conf, ok, err := func() (string, bool, error) {\n")
+	g.printf("conf, ok := lookup(%q)\n", keys[0])
+	g.printf("key := %q\n", keys[0])
+	for _, alias := range keys[1:] {
+		g.printf("if aliasConf, aliasOK := lookup(%q); aliasOK {\n", alias)
+		g.printf("if !ok {\nconf, ok, key = aliasConf, true, %q\n", alias)
+		g.printf("} else if aliasConf != conf {\n")
+		g.printf("return \"\", false, fmt.Errorf(%s, key)\n", errFmt(path, errMsgConflictingKeys+": %s and "+escapeFmt(alias)))
+		g.printf("}\n}\n")
+	}
+	g.printf("return conf, ok, nil\n")
+	g.printf("}(); err != nil {\nreturn err\n} else if ok {\n")
+}
+
+// genValue generates code which parses the string in the variable named conf into target, which has type typ.
+// errFormat is a quoted format string for wrapping errors with %w.
+func (g *generator) genValue(target string, typ types.Type, t phnenv.Tag, conf string, errFormat string) error {
+	if specialTypes[types.TypeString(typ, nil)] {
+		return fmt.Errorf("%w: %s", errUnsupportedType, types.TypeString(typ, nil))
+	}
+
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		return g.genBasic(target, typ, u, t, conf, errFormat)
+	case *types.Pointer:
+		p := g.tmpName("p")
+		g.printf("%s := new(%s)\n", p, g.typeString(u.Elem()))
+
+		err := g.genValue("*"+p, u.Elem(), t, conf, errFormat)
+		if err != nil {
+			return err
+		}
+
+		g.printf("%s = %s\n", target, p)
+	case *types.Slice:
+		if _, ok := u.Elem().Underlying().(*types.Slice); ok && !specialTypes[types.TypeString(u.Elem(), nil)] {
+			return fmt.Errorf("%w: %s", errUnsupportedType, g.typeString(typ))
+		}
+
+		splt, res, i, elemConf := g.tmpName("splt"), g.tmpName("res"), g.tmpName("i"), g.tmpName("conf")
+		g.printf("var %s []string\n", splt)
+		g.printf("if len(%s) > 0 {\n%s = strings.Split(%s, %q)\n}\n", conf, splt, conf, t.Sep)
+		g.printf("%s := make(%s, len(%s))\n", res, g.typeString(typ), splt)
+		g.printf("for %s, %s := range %s {\n", i, elemConf, splt)
+
+		err := g.genValue(res+"["+i+"]", u.Elem(), t, elemConf, errFormat)
+		if err != nil {
+			return err
+		}
+
+		g.printf("}\n%s = %s\n", target, res)
+		g.imports["strings"] = "strings"
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedType, g.typeString(typ))
+	}
+
+	return nil
+}
+
+func (g *generator) genBasic(target string, typ types.Type, b *types.Basic, t phnenv.Tag, conf string, errFormat string) error {
+	ts := g.typeString(typ)
+
+	switch b.Kind() {
+	case types.String:
+		g.printf("%s = %s\n", target, convert(ts, typ, conf))
+	case types.Bool:
+		g.printf("%s = %s\n", target, convert(ts, typ, "strings.ToLower("+conf+") == \"true\""))
+		g.imports["strings"] = "strings"
+	case types.Int32:
+		if !t.Rune {
+			g.genInt(target, ts, b, t, conf, errFormat)
+			break
+		}
+
+		rns := g.tmpName("rns")
+		g.printf("%s := []rune(%s)\n", rns, conf)
+		g.printf("if len(%s) != 1 {\n", rns)
+		g.printReturnErr(t, errFormat, fmt.Sprintf("errors.New(%q)", errMsgRuneLength))
+		g.printf("}\n%s = %s(%s[0])\n", target, ts, rns)
+		g.imports["errors"] = "errors"
+	case types.Int, types.Int8, types.Int16, types.Int64:
+		g.genInt(target, ts, b, t, conf, errFormat)
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		n := g.tmpName("n")
+		g.printf("%s, err := strconv.ParseUint(%s, %d, %d)\n", n, conf, intOpt(t.Base, 10), intOpt(t.BitSize, 64))
+		g.printErrCheck(t, errFormat)
+		if b.Kind() != types.Uint64 {
+			g.printOverflowCheck(t, fmt.Sprintf("uint64(%s(%s)) != %s", ts, n, n), errFormat)
+		}
+		g.printf("%s = %s(%s)\n", target, ts, n)
+	case types.Float32, types.Float64:
+		f := g.tmpName("f")
+		g.printf("%s, err := strconv.ParseFloat(%s, %d)\n", f, conf, intOpt(t.BitSize, 64))
+		g.printErrCheck(t, errFormat)
+		if b.Kind() == types.Float32 {
+			g.printOverflowCheck(t, overflowFloat32(f), errFormat)
+			g.imports["math"] = "math"
+		}
+		g.printf("%s = %s(%s)\n", target, ts, f)
+	case types.Complex64, types.Complex128:
+		c := g.tmpName("c")
+		g.printf("%s, err := strconv.ParseComplex(%s, %d)\n", c, conf, intOpt(t.BitSize, 128))
+		g.printErrCheck(t, errFormat)
+		if b.Kind() == types.Complex64 {
+			g.printOverflowCheck(t, overflowFloat32("real("+c+")")+" || "+overflowFloat32("imag("+c+")"), errFormat)
+			g.imports["math"] = "math"
+		}
+		g.printf("%s = %s(%s)\n", target, ts, c)
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedType, ts)
+	}
+
+	return nil
+}
+
+func (g *generator) genInt(target string, ts string, b *types.Basic, t phnenv.Tag, conf string, errFormat string) {
+	n := g.tmpName("n")
+	g.printf("%s, err := strconv.ParseInt(%s, %d, %d)\n", n, conf, intOpt(t.Base, 10), intOpt(t.BitSize, 64))
+	g.printErrCheck(t, errFormat)
+	if b.Kind() != types.Int64 {
+		g.printOverflowCheck(t, fmt.Sprintf("int64(%s(%s)) != %s", ts, n, n), errFormat)
+	}
+	g.printf("%s = %s(%s)\n", target, ts, n)
+}
+
+func (g *generator) printErrCheck(t phnenv.Tag, errFormat string) {
+	g.printf("if err != nil {\n")
+	g.printReturnErr(t, errFormat, "err")
+	g.printf("}\n")
+	g.imports["strconv"] = "strconv"
+}
+
+func (g *generator) printOverflowCheck(t phnenv.Tag, cond string, errFormat string) {
+	g.printf("if %s {\n", cond)
+	g.printReturnErr(t, errFormat, fmt.Sprintf("errors.New(%q)", errMsgNumericOverflow))
+	g.printf("}\n")
+	g.imports["errors"] = "errors"
+}
+
+// printReturnErr prints a return of the error err, wrapped by errFormat. Like phnenv.Parse, a generic error is
+// returned instead for secret fields, because parsing errors usually quote the value.
+func (g *generator) printReturnErr(t phnenv.Tag, errFormat string, err string) {
+	if t.Secret {
+		err = fmt.Sprintf("errors.New(%q)", errMsgSecretInvalid)
+		g.imports["errors"] = "errors"
+	}
+
+	g.printf("return fmt.Errorf(%s, %s)\n", errFormat, err)
+}
+
+// overflowFloat32 returns a condition which is true in the same cases as reflect.Value.OverflowFloat for a float32.
+// The generated file must import math.
+func overflowFloat32(f string) string {
+	return fmt.Sprintf("(math.Abs(%s) > math.MaxFloat32 && math.Abs(%s) <= math.MaxFloat64)", f, f)
+}
+
+func (g *generator) tmpName(prefix string) string {
+	g.tmp++
+
+	return prefix + strconv.Itoa(g.tmp)
+}
+
+// typeString formats typ as it must be written in the generated file, recording any imports it needs.
+func (g *generator) typeString(typ types.Type) string {
+	return types.TypeString(typ, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+
+		g.imports[p.Path()] = p.Name()
+
+		return p.Name()
+	})
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+func (g *generator) source() ([]byte, error) {
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by phnenv-gen; DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkg.Name())
+	for _, path := range paths {
+		fmt.Fprintf(&buf, "%q\n", path)
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+	buf.Write(g.body.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+// hasTags reports whether any field of st or its nested structs has a phnenv tag.
+// visiting holds the structs being checked further up the current path, so that self-referential types end.
+func hasTags(st *types.Struct, visiting map[*types.Struct]bool) bool {
+	if visiting[st] {
+		return false
+	}
+	visiting[st] = true
+	defer delete(visiting, st)
+
+	for i := 0; i < st.NumFields(); i++ {
+		if nested, ok := structType(st.Field(i).Type()); ok {
+			if hasTags(nested, visiting) {
+				return true
+			}
+
+			continue
+		}
+
+		if _, ok := reflect.StructTag(st.Tag(i)).Lookup(phnEnvStructTag); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// structType returns the struct which typ is or points to, unless it is one of the special types
+// which phnenv.Parse does not recurse into.
+func structType(typ types.Type) (*types.Struct, bool) {
+	for {
+		if specialTypes[types.TypeString(typ, nil)] {
+			return nil, false
+		}
+
+		switch u := typ.Underlying().(type) {
+		case *types.Pointer:
+			typ = u.Elem()
+		case *types.Struct:
+			return u, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// convert returns expr converted to the named type ts, unless typ is not a named type.
+func convert(ts string, typ types.Type, expr string) string {
+	if types.Identical(typ, typ.Underlying()) {
+		return expr
+	}
+
+	return ts + "(" + expr + ")"
+}
+
+func intOpt(opt *int, def int) int {
+	if opt == nil {
+		return def
+	}
+
+	return *opt
+}
+
+// errFmt returns a quoted format string which produces the same error message as phnenv.Parse for the field at path.
+func errFmt(path []string, suffix string) string {
+	var sb strings.Builder
+	sb.WriteString("phnenv: ")
+	for _, name := range path {
+		fmt.Fprintf(&sb, "field %q: ", name)
+	}
+	sb.WriteString(suffix)
+
+	return strconv.Quote(sb.String())
+}
+
+func escapeFmt(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
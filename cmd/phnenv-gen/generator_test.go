@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_generate_GentestPackage_MatchesCommittedFile(t *testing.T) {
+	dir := filepath.Join("internal", "gentest")
+
+	src, err := generate(dir, []string{"Config", "nestedConfig"}, "config_phnenv.go")
+
+	if assert.Nil(t, err) {
+		committed, err := os.ReadFile(filepath.Join(dir, "config_phnenv.go"))
+		assert.Nil(t, err)
+		assert.Equal(t, string(committed), string(src), "run go generate ./... to update the generated file")
+	}
+}
+
+var test_generate_UnsupportedStruct_ReturnsError = []struct {
+	TypeName    string
+	ExpectedErr error
+	ErrPart     string
+}{
+	{"ByteSize", errUnsupportedOption, "ByteSize: field F: tag option is not supported by phnenv-gen: bytesize"},
+	{"Required", errUnsupportedOption, "Required: field F: tag option is not supported by phnenv-gen: required"},
+	{"Unset", errUnsupportedOption, "Unset: field F: tag option is not supported by phnenv-gen: unset"},
+	{"Credential", errUnsupportedOption, "Credential: field F: tag option is not supported by phnenv-gen: credential:"},
+	{"URL", errUnsupportedType, "URL: field F: field type is not supported by phnenv-gen: net/url.URL"},
+	{"Map", errUnsupportedType, "map[string]string"},
+	{"NestedSlice", errUnsupportedType, "NestedSlice: field Nested: field F:"},
+	{"Unexported", errUnexportedField, "Unexported: field f:"},
+	{"UnexportedNested", errUnexportedField, "UnexportedNested: field n:"},
+	{"BadTag", nil, "struct tag base option must only be provided once"},
+	{"NotStruct", errNotStruct, "NotStruct:"},
+	{"Missing", errTypeNotFound, "Missing:"},
+}
+
+func Test_generate_UnsupportedStruct_ReturnsError(t *testing.T) {
+	for _, c := range test_generate_UnsupportedStruct_ReturnsError {
+		t.Run(c.TypeName, func(t *testing.T) {
+			_, err := generate(filepath.Join("testdata", "unsupported"), []string{c.TypeName}, "out.go")
+
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), c.ErrPart)
+				if c.ExpectedErr != nil {
+					assert.True(t, errors.Is(err, c.ExpectedErr))
+				}
+			}
+		})
+	}
+}
+
+var test_parseFuncName = []struct {
+	TypeName string
+	Expected string
+}{
+	{"Config", "ParseConfig"},
+	{"config", "parseConfig"},
+	{"éclair", "parseÉclair"},
+}
+
+func Test_parseFuncName(t *testing.T) {
+	for _, c := range test_parseFuncName {
+		t.Run(c.TypeName, func(t *testing.T) {
+			assert.Equal(t, c.Expected, parseFuncName(c.TypeName))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Package gentest holds a config struct and the loader generated for it by phnenv-gen,
+// to check that the generated code behaves the same as phnenv.Parse.
+package gentest
+
+import (
+	"sync"
+	"time"
+)
+
+//go:generate go run github.com/phonaputer/phnenv/cmd/phnenv-gen -type Config,nestedConfig
+
+type Level int8
+
+type Name string
+
+type Config struct {
+	Str       string     `phnenv:"STR"`
+	Bool      bool       `phnenv:"BOOL"`
+	Int       int        `phnenv:"INT"`
+	Int8      int8       `phnenv:"INT8"`
+	Int16     int16      `phnenv:"INT16,base:16"`
+	Int32     int32      `phnenv:"INT32"`
+	Rune      rune       `phnenv:"RUNE,rune"`
+	Int64     int64      `phnenv:"INT64,bitsize:32"`
+	Uint      uint       `phnenv:"UINT"`
+	Uint8     uint8      `phnenv:"UINT8,base:2"`
+	Uint16    uint16     `phnenv:"UINT16"`
+	Uint32    uint32     `phnenv:"UINT32"`
+	Uint64    uint64     `phnenv:"UINT64"`
+	Float32   float32    `phnenv:"FLOAT32"`
+	Float64   float64    `phnenv:"FLOAT64"`
+	Complex64 complex64  `phnenv:"COMPLEX64"`
+	Complex   complex128 `phnenv:"COMPLEX128"`
+	Level     Level      `phnenv:"LEVEL"`
+	Name      Name       `phnenv:"NAME,noexpand"`
+	IntPtr    *int       `phnenv:"INT_PTR"`
+	IntPtrPtr **int      `phnenv:"INT_PTR_PTR"`
+	Strs      []string   `phnenv:"STRS"`
+	Runes     []rune     `phnenv:"RUNES,rune,sep:|"`
+	UintPtrs  []*uint    `phnenv:"UINT_PTRS,base:16"`
+	Names     *[]Name    `phnenv:"NAMES"`
+	Renamed   string     `phnenv:"NEW_NAME|OLD_NAME|OLDEST_NAME"`
+	PIN       int        `phnenv:"PIN,secret"`
+	Untagged  string
+	Nested    nestedConfig
+	NestedPtr **nestedConfig
+	Anon      *struct {
+		A string `phnenv:"ANON_A"`
+	}
+	Started time.Time
+	Plain   *struct{ X string }
+	mu      *sync.Mutex
+}
+
+type nestedConfig struct {
+	A     string `phnenv:"NESTED_A"`
+	Inner struct {
+		B []float64 `phnenv:"NESTED_INNER_B"`
+	}
+}
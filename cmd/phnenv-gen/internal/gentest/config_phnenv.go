@@ -0,0 +1,363 @@
+// Code generated by phnenv-gen; DO NOT EDIT.
+
+package gentest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseConfig fills v with config values returned by lookup, in the same way as phnenv.Parse
+// called without any Options. To read the OS environment, pass os.LookupEnv as lookup.
+func ParseConfig(v *Config, lookup func(string) (string, bool)) error {
+	if conf, ok := lookup("STR"); ok {
+		v.Str = conf
+	}
+	if conf, ok := lookup("BOOL"); ok {
+		v.Bool = strings.ToLower(conf) == "true"
+	}
+	if conf, ok := lookup("INT"); ok {
+		n1, err := strconv.ParseInt(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Int\": %w", err)
+		}
+		if int64(int(n1)) != n1 {
+			return fmt.Errorf("phnenv: field \"Int\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Int = int(n1)
+	}
+	if conf, ok := lookup("INT8"); ok {
+		n2, err := strconv.ParseInt(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Int8\": %w", err)
+		}
+		if int64(int8(n2)) != n2 {
+			return fmt.Errorf("phnenv: field \"Int8\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Int8 = int8(n2)
+	}
+	if conf, ok := lookup("INT16"); ok {
+		n3, err := strconv.ParseInt(conf, 16, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Int16\": %w", err)
+		}
+		if int64(int16(n3)) != n3 {
+			return fmt.Errorf("phnenv: field \"Int16\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Int16 = int16(n3)
+	}
+	if conf, ok := lookup("INT32"); ok {
+		n4, err := strconv.ParseInt(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Int32\": %w", err)
+		}
+		if int64(int32(n4)) != n4 {
+			return fmt.Errorf("phnenv: field \"Int32\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Int32 = int32(n4)
+	}
+	if conf, ok := lookup("RUNE"); ok {
+		rns5 := []rune(conf)
+		if len(rns5) != 1 {
+			return fmt.Errorf("phnenv: field \"Rune\": %w", errors.New("less/more than 1 rune found for rune type"))
+		}
+		v.Rune = rune(rns5[0])
+	}
+	if conf, ok := lookup("INT64"); ok {
+		n6, err := strconv.ParseInt(conf, 10, 32)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Int64\": %w", err)
+		}
+		v.Int64 = int64(n6)
+	}
+	if conf, ok := lookup("UINT"); ok {
+		n7, err := strconv.ParseUint(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Uint\": %w", err)
+		}
+		if uint64(uint(n7)) != n7 {
+			return fmt.Errorf("phnenv: field \"Uint\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Uint = uint(n7)
+	}
+	if conf, ok := lookup("UINT8"); ok {
+		n8, err := strconv.ParseUint(conf, 2, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Uint8\": %w", err)
+		}
+		if uint64(uint8(n8)) != n8 {
+			return fmt.Errorf("phnenv: field \"Uint8\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Uint8 = uint8(n8)
+	}
+	if conf, ok := lookup("UINT16"); ok {
+		n9, err := strconv.ParseUint(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Uint16\": %w", err)
+		}
+		if uint64(uint16(n9)) != n9 {
+			return fmt.Errorf("phnenv: field \"Uint16\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Uint16 = uint16(n9)
+	}
+	if conf, ok := lookup("UINT32"); ok {
+		n10, err := strconv.ParseUint(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Uint32\": %w", err)
+		}
+		if uint64(uint32(n10)) != n10 {
+			return fmt.Errorf("phnenv: field \"Uint32\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Uint32 = uint32(n10)
+	}
+	if conf, ok := lookup("UINT64"); ok {
+		n11, err := strconv.ParseUint(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Uint64\": %w", err)
+		}
+		v.Uint64 = uint64(n11)
+	}
+	if conf, ok := lookup("FLOAT32"); ok {
+		f12, err := strconv.ParseFloat(conf, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Float32\": %w", err)
+		}
+		if math.Abs(f12) > math.MaxFloat32 && math.Abs(f12) <= math.MaxFloat64 {
+			return fmt.Errorf("phnenv: field \"Float32\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Float32 = float32(f12)
+	}
+	if conf, ok := lookup("FLOAT64"); ok {
+		f13, err := strconv.ParseFloat(conf, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Float64\": %w", err)
+		}
+		v.Float64 = float64(f13)
+	}
+	if conf, ok := lookup("COMPLEX64"); ok {
+		c14, err := strconv.ParseComplex(conf, 128)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Complex64\": %w", err)
+		}
+		if (math.Abs(real(c14)) > math.MaxFloat32 && math.Abs(real(c14)) <= math.MaxFloat64) || (math.Abs(imag(c14)) > math.MaxFloat32 && math.Abs(imag(c14)) <= math.MaxFloat64) {
+			return fmt.Errorf("phnenv: field \"Complex64\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Complex64 = complex64(c14)
+	}
+	if conf, ok := lookup("COMPLEX128"); ok {
+		c15, err := strconv.ParseComplex(conf, 128)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Complex\": %w", err)
+		}
+		v.Complex = complex128(c15)
+	}
+	if conf, ok := lookup("LEVEL"); ok {
+		n16, err := strconv.ParseInt(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"Level\": %w", err)
+		}
+		if int64(Level(n16)) != n16 {
+			return fmt.Errorf("phnenv: field \"Level\": %w", errors.New("environment value overflows numeric type"))
+		}
+		v.Level = Level(n16)
+	}
+	if conf, ok := lookup("NAME"); ok {
+		v.Name = Name(conf)
+	}
+	if conf, ok := lookup("INT_PTR"); ok {
+		p17 := new(int)
+		n18, err := strconv.ParseInt(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"IntPtr\": %w", err)
+		}
+		if int64(int(n18)) != n18 {
+			return fmt.Errorf("phnenv: field \"IntPtr\": %w", errors.New("environment value overflows numeric type"))
+		}
+		*p17 = int(n18)
+		v.IntPtr = p17
+	}
+	if conf, ok := lookup("INT_PTR_PTR"); ok {
+		p19 := new(*int)
+		p20 := new(int)
+		n21, err := strconv.ParseInt(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"IntPtrPtr\": %w", err)
+		}
+		if int64(int(n21)) != n21 {
+			return fmt.Errorf("phnenv: field \"IntPtrPtr\": %w", errors.New("environment value overflows numeric type"))
+		}
+		*p20 = int(n21)
+		*p19 = p20
+		v.IntPtrPtr = p19
+	}
+	if conf, ok := lookup("STRS"); ok {
+		var splt22 []string
+		if len(conf) > 0 {
+			splt22 = strings.Split(conf, ",")
+		}
+		res23 := make([]string, len(splt22))
+		for i24, conf25 := range splt22 {
+			res23[i24] = conf25
+		}
+		v.Strs = res23
+	}
+	if conf, ok := lookup("RUNES"); ok {
+		var splt26 []string
+		if len(conf) > 0 {
+			splt26 = strings.Split(conf, "|")
+		}
+		res27 := make([]rune, len(splt26))
+		for i28, conf29 := range splt26 {
+			rns30 := []rune(conf29)
+			if len(rns30) != 1 {
+				return fmt.Errorf("phnenv: field \"Runes\": %w", errors.New("less/more than 1 rune found for rune type"))
+			}
+			res27[i28] = rune(rns30[0])
+		}
+		v.Runes = res27
+	}
+	if conf, ok := lookup("UINT_PTRS"); ok {
+		var splt31 []string
+		if len(conf) > 0 {
+			splt31 = strings.Split(conf, ",")
+		}
+		res32 := make([]*uint, len(splt31))
+		for i33, conf34 := range splt31 {
+			p35 := new(uint)
+			n36, err := strconv.ParseUint(conf34, 16, 64)
+			if err != nil {
+				return fmt.Errorf("phnenv: field \"UintPtrs\": %w", err)
+			}
+			if uint64(uint(n36)) != n36 {
+				return fmt.Errorf("phnenv: field \"UintPtrs\": %w", errors.New("environment value overflows numeric type"))
+			}
+			*p35 = uint(n36)
+			res32[i33] = p35
+		}
+		v.UintPtrs = res32
+	}
+	if conf, ok := lookup("NAMES"); ok {
+		p37 := new([]Name)
+		var splt38 []string
+		if len(conf) > 0 {
+			splt38 = strings.Split(conf, ",")
+		}
+		res39 := make([]Name, len(splt38))
+		for i40, conf41 := range splt38 {
+			res39[i40] = Name(conf41)
+		}
+		*p37 = res39
+		v.Names = p37
+	}
+	if conf, ok, err := func() (string, bool, error) {
+		conf, ok := lookup("NEW_NAME")
+		key := "NEW_NAME"
+		if aliasConf, aliasOK := lookup("OLD_NAME"); aliasOK {
+			if !ok {
+				conf, ok, key = aliasConf, true, "OLD_NAME"
+			} else if aliasConf != conf {
+				return "", false, fmt.Errorf("phnenv: field \"Renamed\": aliased environment variables are set to different values: %s and OLD_NAME", key)
+			}
+		}
+		if aliasConf, aliasOK := lookup("OLDEST_NAME"); aliasOK {
+			if !ok {
+				conf, ok, key = aliasConf, true, "OLDEST_NAME"
+			} else if aliasConf != conf {
+				return "", false, fmt.Errorf("phnenv: field \"Renamed\": aliased environment variables are set to different values: %s and OLDEST_NAME", key)
+			}
+		}
+		return conf, ok, nil
+	}(); err != nil {
+		return err
+	} else if ok {
+		v.Renamed = conf
+	}
+	if conf, ok := lookup("PIN"); ok {
+		n42, err := strconv.ParseInt(conf, 10, 64)
+		if err != nil {
+			return fmt.Errorf("phnenv: field \"PIN\": %w", errors.New("invalid value for secret field, not shown"))
+		}
+		if int64(int(n42)) != n42 {
+			return fmt.Errorf("phnenv: field \"PIN\": %w", errors.New("invalid value for secret field, not shown"))
+		}
+		v.PIN = int(n42)
+	}
+	if conf, ok := lookup("NESTED_A"); ok {
+		v.Nested.A = conf
+	}
+	if conf, ok := lookup("NESTED_INNER_B"); ok {
+		var splt43 []string
+		if len(conf) > 0 {
+			splt43 = strings.Split(conf, ",")
+		}
+		res44 := make([]float64, len(splt43))
+		for i45, conf46 := range splt43 {
+			f47, err := strconv.ParseFloat(conf46, 64)
+			if err != nil {
+				return fmt.Errorf("phnenv: field \"Nested\": field \"Inner\": field \"B\": %w", err)
+			}
+			res44[i45] = float64(f47)
+		}
+		v.Nested.Inner.B = res44
+	}
+	if v.NestedPtr == nil {
+		v.NestedPtr = new(*nestedConfig)
+	}
+	if (*v.NestedPtr) == nil {
+		(*v.NestedPtr) = new(nestedConfig)
+	}
+	if conf, ok := lookup("NESTED_A"); ok {
+		(*(*v.NestedPtr)).A = conf
+	}
+	if conf, ok := lookup("NESTED_INNER_B"); ok {
+		var splt48 []string
+		if len(conf) > 0 {
+			splt48 = strings.Split(conf, ",")
+		}
+		res49 := make([]float64, len(splt48))
+		for i50, conf51 := range splt48 {
+			f52, err := strconv.ParseFloat(conf51, 64)
+			if err != nil {
+				return fmt.Errorf("phnenv: field \"NestedPtr\": field \"Inner\": field \"B\": %w", err)
+			}
+			res49[i50] = float64(f52)
+		}
+		(*(*v.NestedPtr)).Inner.B = res49
+	}
+	if v.Anon == nil {
+		v.Anon = new(struct {
+			A string "phnenv:\"ANON_A\""
+		})
+	}
+	if conf, ok := lookup("ANON_A"); ok {
+		(*v.Anon).A = conf
+	}
+	return nil
+}
+
+// parseNestedConfig fills v with config values returned by lookup, in the same way as phnenv.Parse
+// called without any Options. To read the OS environment, pass os.LookupEnv as lookup.
+func parseNestedConfig(v *nestedConfig, lookup func(string) (string, bool)) error {
+	if conf, ok := lookup("NESTED_A"); ok {
+		v.A = conf
+	}
+	if conf, ok := lookup("NESTED_INNER_B"); ok {
+		var splt53 []string
+		if len(conf) > 0 {
+			splt53 = strings.Split(conf, ",")
+		}
+		res54 := make([]float64, len(splt53))
+		for i55, conf56 := range splt53 {
+			f57, err := strconv.ParseFloat(conf56, 64)
+			if err != nil {
+				return fmt.Errorf("phnenv: field \"Inner\": field \"B\": %w", err)
+			}
+			res54[i55] = float64(f57)
+		}
+		v.Inner.B = res54
+	}
+	return nil
+}
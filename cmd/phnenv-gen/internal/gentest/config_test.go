@@ -0,0 +1,105 @@
+package gentest
+
+import (
+	"github.com/phonaputer/phnenv"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var test_ParseConfig_SameResultAsReflection = []struct {
+	Name string
+	Conf map[string]string
+}{
+	{"empty", map[string]string{}},
+	{"all fields", map[string]string{
+		"STR":            "hello",
+		"BOOL":           "TRUE",
+		"INT":            "-123",
+		"INT8":           "-128",
+		"INT16":          "7fff",
+		"INT32":          "2147483647",
+		"RUNE":           "字",
+		"INT64":          "-2147483648",
+		"UINT":           "123",
+		"UINT8":          "11111111",
+		"UINT16":         "65535",
+		"UINT32":         "4294967295",
+		"UINT64":         "18446744073709551615",
+		"FLOAT32":        "3.25",
+		"FLOAT64":        "-1e300",
+		"COMPLEX64":      "1+2i",
+		"COMPLEX128":     "(3-4i)",
+		"LEVEL":          "-3",
+		"NAME":           "${NOT_EXPANDED}",
+		"INT_PTR":        "5",
+		"INT_PTR_PTR":    "6",
+		"STRS":           "a,,b",
+		"RUNES":          "x|y|z",
+		"UINT_PTRS":      "ff,10",
+		"NAMES":          "",
+		"NEW_NAME":       "new",
+		"PIN":            "1234",
+		"NESTED_A":       "nested",
+		"NESTED_INNER_B": "1.5,2.5",
+		"ANON_A":         "anon",
+	}},
+	{"bool not true", map[string]string{"BOOL": "yes"}},
+	{"old alias", map[string]string{"OLDEST_NAME": "oldest"}},
+	{"matching aliases", map[string]string{"OLD_NAME": "old", "OLDEST_NAME": "old"}},
+	{"conflicting aliases", map[string]string{"OLD_NAME": "old", "OLDEST_NAME": "oldest"}},
+	{"invalid int", map[string]string{"INT": "abc"}},
+	{"int8 overflow", map[string]string{"INT8": "128"}},
+	{"int16 base", map[string]string{"INT16": "zz"}},
+	{"int64 bitsize", map[string]string{"INT64": "2147483648"}},
+	{"rune length", map[string]string{"RUNE": "ab"}},
+	{"named type overflow", map[string]string{"LEVEL": "200"}},
+	{"uint negative", map[string]string{"UINT": "-1"}},
+	{"uint8 overflow", map[string]string{"UINT8": "100000000"}},
+	{"uint32 overflow", map[string]string{"UINT32": "4294967296"}},
+	{"float32 overflow", map[string]string{"FLOAT32": "3.5e38"}},
+	{"float32 infinity", map[string]string{"FLOAT32": "-Inf"}},
+	{"float64 out of range", map[string]string{"FLOAT64": "1e309"}},
+	{"complex64 overflow", map[string]string{"COMPLEX64": "1+3.5e38i"}},
+	{"invalid complex", map[string]string{"COMPLEX128": "1+"}},
+	{"invalid pointer", map[string]string{"INT_PTR": "x"}},
+	{"invalid slice element", map[string]string{"UINT_PTRS": "ff,zz"}},
+	{"invalid nested", map[string]string{"NESTED_INNER_B": "1,x"}},
+	{"invalid secret", map[string]string{"PIN": "hunter2"}},
+	{"error stops parsing", map[string]string{"STR": "set", "INT": "x", "UINT": "1"}},
+}
+
+func Test_ParseConfig_SameResultAsReflection(t *testing.T) {
+	for _, c := range test_ParseConfig_SameResultAsReflection {
+		t.Run(c.Name, func(t *testing.T) {
+			var reflected, generated Config
+
+			reflectErr := phnenv.Parse(&reflected, phnenv.WithSources(phnenv.Map("test", c.Conf)))
+			generatedErr := ParseConfig(&generated, func(key string) (string, bool) {
+				v, ok := c.Conf[key]
+				return v, ok
+			})
+
+			if reflectErr == nil {
+				assert.Nil(t, generatedErr)
+			} else if assert.NotNil(t, generatedErr) {
+				assert.Equal(t, reflectErr.Error(), generatedErr.Error())
+			}
+			assert.Equal(t, reflected, generated)
+		})
+	}
+}
+
+func Test_parseNestedConfig_SameResultAsReflection(t *testing.T) {
+	conf := map[string]string{"NESTED_A": "a", "NESTED_INNER_B": "1,2"}
+	var reflected, generated nestedConfig
+
+	reflectErr := phnenv.Parse(&reflected, phnenv.WithSources(phnenv.Map("test", conf)))
+	generatedErr := parseNestedConfig(&generated, func(key string) (string, bool) {
+		v, ok := conf[key]
+		return v, ok
+	})
+
+	assert.Nil(t, reflectErr)
+	assert.Nil(t, generatedErr)
+	assert.Equal(t, reflected, generated)
+}
@@ -0,0 +1,79 @@
+// Command phnenv-gen generates functions which load config into phnenv tagged structs without using reflection.
+//
+// The generated functions behave like phnenv.Parse called without any Options, including the error messages
+// they return, but parse each field with direct calls to the strconv package. This makes them suitable for
+// latency sensitive programs.
+//
+// phnenv-gen is intended to be run by go generate. For example, given this file in package config:
+//
+//	//go:generate go run github.com/phonaputer/phnenv/cmd/phnenv-gen -type Config
+//
+//	type Config struct {
+//		Port uint16 `phnenv:"PORT"`
+//	}
+//
+// running go generate writes config_phnenv.go containing:
+//
+//	func ParseConfig(v *Config, lookup func(string) (string, bool)) error
+//
+// The lookup function gets the config value for a key. Pass os.LookupEnv to read the OS environment.
+//
+// The generated functions support fields of type string, bool, int, uint, float and complex (including named
+// types with those underlying types), pointers to and slices of those types, and nested structs.
+// The tag options rune, base:, bitsize:, sep:, noexpand, secret, flag: and desc: are supported, as are key aliases ("NEW|OLD").
+// phnenv-gen returns an error for any other field type or tag option, in which case phnenv.Parse must be used instead.
+//
+// Usage:
+//
+//	phnenv-gen -type T1[,T2...] [-output file] [dir]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of struct type names to generate functions for (required)")
+	output := flag.String("output", "", "output file name (default <dir>/<first type in lower case>_phnenv.go)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: phnenv-gen -type T1[,T2...] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if len(*typeNames) < 1 || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	types := strings.Split(*typeNames, ",")
+
+	outFile := *output
+	if len(outFile) < 1 {
+		outFile = filepath.Join(dir, strings.ToLower(types[0])+"_phnenv.go")
+	}
+
+	err := run(dir, types, outFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "phnenv-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, typeNames []string, outFile string) error {
+	src, err := generate(dir, typeNames, filepath.Base(outFile))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outFile, src, 0644)
+}
@@ -0,0 +1,49 @@
+package unsupported
+
+import "net/url"
+
+type ByteSize struct {
+	F int64 `phnenv:"F,bytesize"`
+}
+
+type Required struct {
+	F string `phnenv:"F,required,desc:must be set"`
+}
+
+type Unset struct {
+	F string `phnenv:"F,unset"`
+}
+
+type Credential struct {
+	F string `phnenv:"F,credential:f"`
+}
+
+type URL struct {
+	F *url.URL `phnenv:"F"`
+}
+
+type Map struct {
+	F map[string]string `phnenv:"F"`
+}
+
+type NestedSlice struct {
+	Nested struct {
+		F [][]string `phnenv:"F"`
+	}
+}
+
+type Unexported struct {
+	f string `phnenv:"F"`
+}
+
+type UnexportedNested struct {
+	n *struct {
+		F string `phnenv:"F"`
+	}
+}
+
+type BadTag struct {
+	F int `phnenv:"F,base:2,base:2"`
+}
+
+type NotStruct int
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/phonaputer/phnenv/cmd/phnenv-vet/phnenvcheck"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// analyzerName names phnenv-vet's checks in the JSON output read by go vet.
+const analyzerName = "phnenvtag"
+
+var errVersionFlag = errors.New("unsupported flag value: -V=")
+
+// vetConfig is the part of the JSON file describing a package, which go vet passes to its vettool, that phnenv-vet uses.
+type vetConfig struct {
+	ID          string
+	Compiler    string
+	ImportPath  string
+	GoFiles     []string
+	ImportMap   map[string]string // import path in source code to package path
+	PackageFile map[string]string // package path to file with export data
+	VetxOnly    bool
+	VetxOutput  string
+	Stdout      string
+
+	SucceedOnTypecheckFailure bool
+}
+
+// printVersion prints the version in the format go vet expects from the -V=full flag,
+// where the build ID identifies the executable so that go vet knows when to rerun it.
+func printVersion(mode string) error {
+	if mode != "full" {
+		return fmt.Errorf("%w%s", errVersionFlag, mode)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s version devel buildID=%02x\n", filepath.Base(os.Args[0]), h.Sum(nil))
+
+	return nil
+}
+
+// checkPackages checks the packages matching patterns, printing the problems found to stderr.
+// The bool result is true if there were any problems.
+func checkPackages(patterns []string) (bool, error) {
+	dirs, err := packageDirs(patterns)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for _, dir := range dirs {
+		fset, files, info, err := loadDir(dir)
+		if err != nil {
+			return found, err
+		}
+
+		if printDiagnostics(fset, phnenvcheck.Check(files, info)) {
+			found = true
+		}
+	}
+
+	return found, nil
+}
+
+// packageDirs returns the directories of the packages matching patterns, as listed by the go command.
+func packageDirs(patterns []string) ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("go", append([]string{"list", "-f", "{{.Dir}}", "--"}, patterns...)...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.Fields(string(out)), nil
+}
+
+// loadDir parses and type checks the package in dir, not including its tests.
+func loadDir(dir string) (*token.FileSet, []*ast.File, *types.Info, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	paths := make([]string, len(bp.GoFiles))
+	for i, name := range bp.GoFiles {
+		paths[i] = filepath.Join(dir, name)
+	}
+
+	fset := token.NewFileSet()
+	files, info, err := typeCheck(fset, bp.ImportPath, paths, importer.ForCompiler(fset, "source", nil))
+
+	return fset, files, info, err
+}
+
+// jsonDiagnostic is a problem in the JSON format which go vet reads from its vettool's output.
+type jsonDiagnostic struct {
+	Posn    string `json:"posn"`
+	End     string `json:"end"`
+	Message string `json:"message"`
+}
+
+// vetPackage checks the package described by the go vet config file at path. The problems found are printed
+// to stderr, or if asJSON is true, written as JSON to the file named in the config and not counted as a failure.
+// The bool result is true if there were any problems.
+func vetPackage(path string, asJSON bool) (bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	var cfg vetConfig
+	err = json.Unmarshal(b, &cfg)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// phnenv-vet records no facts about packages, but go vet caches the output file if it exists
+	if cfg.VetxOutput != "" {
+		err = os.WriteFile(cfg.VetxOutput, nil, 0666)
+		if err != nil {
+			return false, err
+		}
+	}
+	if cfg.VetxOnly {
+		return false, nil
+	}
+
+	fset := token.NewFileSet()
+	imp := vetImporter{
+		importMap: cfg.ImportMap,
+		imp: importer.ForCompiler(fset, cfg.Compiler, func(path string) (io.ReadCloser, error) {
+			file, ok := cfg.PackageFile[path]
+			if !ok {
+				return nil, fmt.Errorf("no export data for %q", path)
+			}
+
+			return os.Open(file)
+		}),
+	}
+
+	files, info, err := typeCheck(fset, cfg.ImportPath, cfg.GoFiles, imp)
+	if err != nil {
+		if cfg.SucceedOnTypecheckFailure {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	diags := phnenvcheck.Check(files, info)
+	if !asJSON {
+		return printDiagnostics(fset, diags), nil
+	}
+
+	return false, writeJSONDiagnostics(cfg, fset, diags)
+}
+
+// writeJSONDiagnostics writes the problems found in the package described by cfg to its Stdout file,
+// or to stdout if it has none, as a map from the package ID to the analyzer name to the problems.
+func writeJSONDiagnostics(cfg vetConfig, fset *token.FileSet, diags []phnenvcheck.Diagnostic) error {
+	if len(diags) < 1 {
+		return nil
+	}
+
+	res := make([]jsonDiagnostic, len(diags))
+	for i, d := range diags {
+		res[i] = jsonDiagnostic{Posn: fset.Position(d.Pos).String(), Message: d.Message}
+	}
+
+	b, err := json.Marshal(map[string]map[string][]jsonDiagnostic{cfg.ID: {analyzerName: res}})
+	if err != nil {
+		return err
+	}
+
+	if cfg.Stdout == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	return os.WriteFile(cfg.Stdout, b, 0666)
+}
+
+// vetImporter imports packages from the export data listed in a go vet config file.
+type vetImporter struct {
+	imp       types.Importer
+	importMap map[string]string
+}
+
+func (vi vetImporter) Import(path string) (*types.Package, error) {
+	if p, ok := vi.importMap[path]; ok {
+		path = p
+	}
+
+	return vi.imp.Import(path)
+}
+
+func typeCheck(fset *token.FileSet, importPath string, paths []string, imp types.Importer) ([]*ast.File, *types.Info, error) {
+	files := make([]*ast.File, 0, len(paths))
+	for _, path := range paths {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Importer: imp}
+
+	_, err := conf.Check(importPath, fset, files, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return files, info, nil
+}
+
+// printDiagnostics prints each problem with its position to stderr, and reports whether there were any.
+func printDiagnostics(fset *token.FileSet, diags []phnenvcheck.Diagnostic) bool {
+	for _, d := range diags {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", fset.Position(d.Pos), d.Message)
+	}
+
+	return len(diags) > 0
+}
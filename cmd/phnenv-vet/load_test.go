@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/phonaputer/phnenv/cmd/phnenv-vet/phnenvcheck"
+	"github.com/stretchr/testify/assert"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_loadDir_TypeChecksPackage(t *testing.T) {
+	fset, files, info, err := loadDir(filepath.Join("phnenvcheck", "testdata", "src", "a"))
+
+	if assert.Nil(t, err) {
+		diags := phnenvcheck.Check(files, info)
+		if assert.NotEmpty(t, diags) {
+			assert.Equal(t, "a.go", filepath.Base(fset.Position(diags[0].Pos).Filename))
+		}
+	}
+}
+
+func Test_writeJSONDiagnostics(t *testing.T) {
+	fset := token.NewFileSet()
+	f := fset.AddFile("a.go", -1, 100)
+	f.SetLines([]int{0, 10})
+	out := filepath.Join(t.TempDir(), "vet.stdout")
+	cfg := vetConfig{ID: "example.com/a", Stdout: out}
+
+	err := writeJSONDiagnostics(cfg, fset, []phnenvcheck.Diagnostic{{Pos: f.Pos(12), Message: "bad tag"}})
+
+	if assert.Nil(t, err) {
+		b, err := os.ReadFile(out)
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"example.com/a": {"phnenvtag": [{"posn": "a.go:2:3", "end": "", "message": "bad tag"}]}}`, string(b))
+	}
+}
+
+func Test_writeJSONDiagnostics_NoProblems_WritesNothing(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "vet.stdout")
+
+	err := writeJSONDiagnostics(vetConfig{ID: "example.com/a", Stdout: out}, token.NewFileSet(), nil)
+
+	if assert.Nil(t, err) {
+		_, err = os.Stat(out)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
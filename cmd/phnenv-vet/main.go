@@ -0,0 +1,81 @@
+// Command phnenv-vet reports mistakes in phnenv struct tags, which phnenv.Parse would otherwise only
+// report at runtime, or silently ignore.
+//
+// It can be run directly on packages:
+//
+//	go run github.com/phonaputer/phnenv/cmd/phnenv-vet@latest ./...
+//
+// or by go vet:
+//
+//	go install github.com/phonaputer/phnenv/cmd/phnenv-vet@latest
+//	go vet -vettool=$(which phnenv-vet) ./...
+//
+// phnenv-vet only uses the standard library, so it is part of the phnenv module.
+// See the phnenvcheck package for the list of checks.
+//
+// Usage:
+//
+//	phnenv-vet [packages]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	exitError       = 1
+	exitDiagnostics = 3
+)
+
+func main() {
+	version := flag.String("V", "", "print version and exit (used by go vet)")
+	printFlags := flag.Bool("flags", false, "print flags as JSON and exit (used by go vet)")
+	asJSON := flag.Bool("json", false, "write the problems found in a go vet package as JSON (used by go vet)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: phnenv-vet [packages]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch {
+	case *version != "":
+		exitOnError(printVersion(*version))
+		return
+	case *printFlags:
+		// phnenv-vet has no flags which go vet should pass on to it
+		fmt.Println("[]")
+		return
+	}
+
+	args := flag.Args()
+
+	// go vet runs its vettool once per package, with the path of a JSON file describing the package
+	if len(args) == 1 && strings.HasSuffix(args[0], ".cfg") {
+		found, err := vetPackage(args[0], *asJSON)
+		exitOnError(err)
+		if found {
+			os.Exit(exitDiagnostics)
+		}
+		return
+	}
+
+	if len(args) < 1 {
+		args = []string{"."}
+	}
+
+	found, err := checkPackages(args)
+	exitOnError(err)
+	if found {
+		os.Exit(exitDiagnostics)
+	}
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "phnenv-vet: %v\n", err)
+		os.Exit(exitError)
+	}
+}
@@ -0,0 +1,405 @@
+// Package phnenvcheck checks phnenv struct tags in type checked Go source files. It only uses the standard library.
+//
+// Check reports:
+//
+//   - struct tag keys which look like misspellings of "phnenv" (such as `phenenv:"PORT"`), which cause the field to be ignored
+//   - phnenv tags which phnenv.Parse would reject, such as `phnenv:"PORT,bitsize:abc"`
+//   - tag options which have no effect on the field's type, such as base: on a string field
+//   - phnenv tags on fields of types which phnenv.Parse does not support, such as maps
+//   - phnenv tags on nested struct fields, which are ignored because the nested struct's own fields are read instead
+//   - keys which are read by more than one field of a struct type, including the fields of its nested structs
+package phnenvcheck
+
+import (
+	"fmt"
+	"github.com/phonaputer/phnenv"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const phnEnvStructTag = "phnenv"
+
+// Diagnostic is a problem found by Check.
+type Diagnostic struct {
+	Pos     token.Pos
+	Message string
+}
+
+// specialTypes are the types which phnenv.Parse parses from a single value, rather than by their kind.
+var specialTypes = map[string]bool{
+	"net.IP":                                true,
+	"net.IPNet":                             true,
+	"net/netip.Addr":                        true,
+	"net/netip.AddrPort":                    true,
+	"net/netip.Prefix":                      true,
+	"net/url.URL":                           true,
+	"github.com/phonaputer/phnenv.HostPort": true,
+}
+
+// kind is the category of a field's type which determines which tag options apply to it.
+type kind int
+
+const (
+	kindOther kind = iota
+	kindInt
+	kindInt32
+	kindUint
+	kindFloat
+	kindComplex
+	kindURL
+)
+
+type checker struct {
+	info        *types.Info
+	diagnostics []Diagnostic
+
+	// reported prevents the same diagnostic being reported twice, when a struct is checked both
+	// on its own and as a nested struct.
+	reported map[string]bool
+}
+
+// Check returns the problems with the phnenv struct tags in files, in the order they are found.
+// The files must be from a single package, which was type checked with info recording Types, Defs and Uses.
+func Check(files []*ast.File, info *types.Info) []Diagnostic {
+	c := &checker{info: info, reported: map[string]bool{}}
+
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.StructType:
+				for _, f := range n.Fields.List {
+					c.checkField(f)
+				}
+			case *ast.TypeSpec:
+				if st, ok := info.TypeOf(n.Type).(*types.Struct); ok {
+					c.checkDuplicateKeys(st, map[string]string{}, map[*types.Struct]bool{}, token.NoPos, "")
+				}
+			}
+
+			return true
+		})
+	}
+
+	return c.diagnostics
+}
+
+func (c *checker) checkField(f *ast.Field) {
+	if f.Tag == nil {
+		return
+	}
+
+	tag, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return
+	}
+
+	for _, key := range tagKeys(tag) {
+		if key != phnEnvStructTag && isMisspelling(key) {
+			c.report(f.Tag.Pos(), "struct tag key %q looks like a misspelling of %q, so the field will be ignored by phnenv", key, phnEnvStructTag)
+		}
+	}
+
+	tagStr, ok := reflect.StructTag(tag).Lookup(phnEnvStructTag)
+	if !ok {
+		return
+	}
+
+	typ := c.info.TypeOf(f.Type)
+	if typ == nil {
+		return
+	}
+
+	if isStruct(typ) {
+		c.report(f.Tag.Pos(), "phnenv tag on a struct field is ignored: the fields of the nested struct are read instead")
+		return
+	}
+
+	t, err := phnenv.ParseTag(tagStr)
+	if err != nil {
+		c.report(f.Tag.Pos(), "invalid phnenv tag: %v", err)
+		return
+	}
+
+	if !isSupported(typ) {
+		c.report(f.Type.Pos(), "phnenv does not support fields of type %s", typ)
+		return
+	}
+
+	c.checkOptions(f.Tag.Pos(), typ, t)
+}
+
+// checkOptions reports options which have no effect on a field of type typ.
+func (c *checker) checkOptions(pos token.Pos, typ types.Type, t phnenv.Tag) {
+	isSlice, k := classify(typ)
+
+	check := func(used bool, name string, kinds ...kind) {
+		if !used {
+			return
+		}
+
+		for _, allowed := range kinds {
+			if k == allowed {
+				return
+			}
+		}
+
+		c.report(pos, "phnenv tag option %s has no effect on a field of type %s", name, typ)
+	}
+
+	check(t.Base != nil, "base:", kindInt, kindInt32, kindUint)
+	check(t.BitSize != nil, "bitsize:", kindInt, kindInt32, kindUint, kindFloat, kindComplex)
+	check(t.Rune, "rune", kindInt32)
+	check(t.ByteSize, "bytesize", kindInt, kindInt32, kindUint)
+	check(t.Absolute, "absolute", kindURL)
+	check(len(t.Schemes) > 0, "schemes:", kindURL)
+
+	if t.Sep != "," && !isSlice {
+		c.report(pos, "phnenv tag option sep: has no effect on a field of type %s", typ)
+	}
+}
+
+// checkDuplicateKeys reports keys which are read by more than one field of st or its nested structs.
+// seen maps each key to the name of the first field which reads it. visiting holds the structs which are being
+// checked further up the current path, so that self-referential types such as linked list nodes are not recursed into forever.
+// Diagnostics are reported at reportPos, the position of the top-level field containing st, or at the field itself
+// when st is the top-level struct.
+func (c *checker) checkDuplicateKeys(st *types.Struct, seen map[string]string, visiting map[*types.Struct]bool, reportPos token.Pos, prefix string) {
+	if visiting[st] {
+		return
+	}
+	visiting[st] = true
+	defer delete(visiting, st)
+
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		name := prefix + f.Name()
+
+		pos := reportPos
+		if !pos.IsValid() {
+			pos = f.Pos()
+		}
+
+		if nested, ok := structOf(f.Type()); ok {
+			c.checkDuplicateKeys(nested, seen, visiting, pos, name+".")
+			continue
+		}
+
+		tagStr, ok := reflect.StructTag(st.Tag(i)).Lookup(phnEnvStructTag)
+		if !ok {
+			continue
+		}
+
+		t, err := phnenv.ParseTag(tagStr)
+		if err != nil {
+			continue // reported by checkField
+		}
+
+		for _, key := range t.Keys {
+			if first, ok := seen[key]; ok {
+				c.report(pos, "phnenv key %s of field %s is also read by field %s", key, name, first)
+				continue
+			}
+
+			seen[key] = name
+		}
+	}
+}
+
+func (c *checker) report(pos token.Pos, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	id := fmt.Sprintf("%d:%s", pos, msg)
+	if c.reported[id] || !pos.IsValid() {
+		return
+	}
+
+	c.reported[id] = true
+	c.diagnostics = append(c.diagnostics, Diagnostic{Pos: pos, Message: msg})
+}
+
+// tagKeys returns the keys in a struct tag, following the conventional format used by reflect.StructTag.
+func tagKeys(tag string) []string {
+	var keys []string
+
+	for tag != "" {
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		keys = append(keys, tag[:i])
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		tag = tag[i+1:]
+	}
+
+	return keys
+}
+
+// isMisspelling reports whether a struct tag key is close enough to "phnenv" to probably be a typo.
+func isMisspelling(key string) bool {
+	return editDistance(strings.ToLower(key), phnEnvStructTag) <= 2
+}
+
+func isSpecial(typ types.Type) bool {
+	return specialTypes[types.TypeString(typ, nil)]
+}
+
+func isStruct(typ types.Type) bool {
+	_, ok := structOf(typ)
+
+	return ok
+}
+
+// structOf returns the struct which typ is or points to, if phnenv.Parse would read its fields as a nested struct.
+func structOf(typ types.Type) (*types.Struct, bool) {
+	for !isSpecial(typ) {
+		switch u := typ.Underlying().(type) {
+		case *types.Pointer:
+			typ = u.Elem()
+		case *types.Struct:
+			return u, true
+		default:
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+// isSupported reports whether phnenv.Parse can parse a config value into a field of type typ.
+func isSupported(typ types.Type) bool {
+	if isSpecial(typ) {
+		return true
+	}
+
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&(types.IsBoolean|types.IsString|types.IsInteger|types.IsFloat|types.IsComplex) != 0 &&
+			u.Info()&types.IsUntyped == 0 &&
+			u.Kind() != types.Uintptr
+	case *types.Pointer:
+		return isSupported(u.Elem())
+	case *types.Slice:
+		elem := u.Elem()
+		if isSpecial(elem) {
+			return true
+		}
+		if _, ok := elem.Underlying().(*types.Slice); ok {
+			return false
+		}
+		if _, ok := elem.Underlying().(*types.Struct); ok {
+			return false
+		}
+
+		return isSupported(elem)
+	default:
+		return false
+	}
+}
+
+// classify returns whether typ is a slice (possibly behind pointers), and the kind of its values or elements.
+func classify(typ types.Type) (bool, kind) {
+	isSlice := false
+	typ = derefPointers(typ)
+	if s, ok := typ.Underlying().(*types.Slice); ok && !isSpecial(typ) {
+		isSlice = true
+		typ = derefPointers(s.Elem())
+	}
+
+	if types.TypeString(typ, nil) == "net/url.URL" {
+		return isSlice, kindURL
+	}
+
+	b, ok := typ.Underlying().(*types.Basic)
+	if !ok || isSpecial(typ) {
+		return isSlice, kindOther
+	}
+
+	switch b.Kind() {
+	case types.Int32:
+		return isSlice, kindInt32
+	case types.Int, types.Int8, types.Int16, types.Int64:
+		return isSlice, kindInt
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		return isSlice, kindUint
+	case types.Float32, types.Float64:
+		return isSlice, kindFloat
+	case types.Complex64, types.Complex128:
+		return isSlice, kindComplex
+	default:
+		return isSlice, kindOther
+	}
+}
+
+func derefPointers(typ types.Type) types.Type {
+	for !isSpecial(typ) {
+		ptr, ok := typ.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+
+		typ = ptr.Elem()
+	}
+
+	return typ
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(br)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,119 @@
+package phnenvcheck
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// wantPattern matches the patterns in a "// want" comment, which each match a diagnostic expected on its line.
+var wantPattern = regexp.MustCompile("`([^`]*)`")
+
+func Test_Check(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filepath.Join("testdata", "src", "a", "a.go"), nil, parser.ParseComments)
+	if !assert.Nil(t, err) {
+		return
+	}
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("a", fset, []*ast.File{f}, info)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	want := map[int][]string{}
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "// want ") {
+				line := fset.Position(c.Pos()).Line
+				for _, m := range wantPattern.FindAllStringSubmatch(c.Text, -1) {
+					want[line] = append(want[line], m[1])
+				}
+			}
+		}
+	}
+
+	got := map[int][]string{}
+	for _, d := range Check([]*ast.File{f}, info) {
+		line := fset.Position(d.Pos).Line
+		got[line] = append(got[line], d.Message)
+	}
+
+	for line, patterns := range want {
+		msgs := got[line]
+		if !assert.Len(t, msgs, len(patterns), "line %d: %q", line, msgs) {
+			continue
+		}
+
+		for _, p := range patterns {
+			assert.True(t, matchesAny(p, msgs), "line %d: no diagnostic matches %q in %q", line, p, msgs)
+		}
+	}
+	for line, msgs := range got {
+		assert.Contains(t, want, line, "line %d: unexpected diagnostics %q", line, msgs)
+	}
+}
+
+func matchesAny(pattern string, msgs []string) bool {
+	for _, msg := range msgs {
+		if regexp.MustCompile(pattern).MatchString(msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var test_isMisspelling = []struct {
+	name     string
+	key      string
+	expected bool
+}{
+	{"transposition", "phnevn", true},
+	{"extra letter", "phenenv", true},
+	{"missing letter", "phnen", true},
+	{"upper case", "PHNENV", true},
+	{"different tag", "json", false},
+	{"env tag", "env", false},
+	{"empty", "", false},
+}
+
+func Test_isMisspelling(t *testing.T) {
+	for _, tt := range test_isMisspelling {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isMisspelling(tt.key))
+		})
+	}
+}
+
+var test_tagKeys = []struct {
+	name     string
+	tag      string
+	expected []string
+}{
+	{"single", `phnenv:"A"`, []string{"phnenv"}},
+	{"multiple", `json:"a,omitempty" phnenv:"A"`, []string{"json", "phnenv"}},
+	{"escaped quote", `a:"x\"y" b:"z"`, []string{"a", "b"}},
+	{"malformed", `phnenv`, nil},
+	{"empty", ``, nil},
+}
+
+func Test_tagKeys(t *testing.T) {
+	for _, tt := range test_tagKeys {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tagKeys(tt.tag))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package a
+
+import (
+	"net"
+	"net/url"
+	"time"
+)
+
+type valid struct {
+	Port     int           `phnenv:"PORT,base:16,bitsize:16"`
+	Letter   rune          `phnenv:"LETTER,rune"`
+	Size     uint64        `phnenv:"SIZE,bytesize"`
+	Hosts    []string      `phnenv:"HOSTS,sep:;"`
+	Ptr      *float32      `phnenv:"PTR,bitsize:32"`
+	IPs      []net.IP      `phnenv:"IPS,sep: "`
+	Net      net.IPNet     `phnenv:"NET"`
+	API      url.URL       `phnenv:"API,absolute,schemes:http|https"`
+	Timeout  time.Duration `phnenv:"TIMEOUT"`
+	Renamed  string        `phnenv:"NEW_NAME|OLD_NAME"`
+	Nested   nested
+	Untagged map[string]string
+	Other    string `json:"other" env:"OTHER"`
+}
+
+type nested struct {
+	Name string `phnenv:"NESTED_NAME"`
+}
+
+type misspelled struct {
+	A string `phenenv:"A"`            // want `struct tag key "phenenv" looks like a misspelling of "phnenv"`
+	B string `json:"b" phnvenv:"B"`   // want `struct tag key "phnvenv" looks like a misspelling of "phnenv"`
+	C string `PHNENV:"C"`             // want `struct tag key "PHNENV" looks like a misspelling of "phnenv"`
+	D string `phnenv:"D" phennv:"D2"` // want `struct tag key "phennv" looks like a misspelling of "phnenv"`
+}
+
+type invalid struct {
+	A int    `phnenv:"A,bitsize:abc"`    // want `invalid phnenv tag: .*`
+	B int    `phnenv:""`                 // want `invalid phnenv tag: .*`
+	C int    `phnenv:"C,base:16,base:8"` // want `invalid phnenv tag: .*`
+	D string `phnenv:"D,unknown"`        // want `invalid phnenv tag: .*`
+}
+
+type wrongKind struct {
+	A string   `phnenv:"A,base:16"`     // want `phnenv tag option base: has no effect on a field of type string`
+	B float64  `phnenv:"B,rune"`        // want `phnenv tag option rune has no effect on a field of type float64`
+	C bool     `phnenv:"C,bitsize:8"`   // want `phnenv tag option bitsize: has no effect on a field of type bool`
+	D string   `phnenv:"D,sep:;"`       // want `phnenv tag option sep: has no effect on a field of type string`
+	E string   `phnenv:"E,absolute"`    // want `phnenv tag option absolute has no effect on a field of type string`
+	F []string `phnenv:"F,schemes:ftp"` // want `phnenv tag option schemes: has no effect on a field of type \[\]string`
+	G float32  `phnenv:"G,bytesize"`    // want `phnenv tag option bytesize has no effect on a field of type float32`
+	H []int    `phnenv:"H,base:2"`
+}
+
+type unsupported struct {
+	A map[string]string `phnenv:"A"` // want `phnenv does not support fields of type map\[string\]string`
+	B chan int          `phnenv:"B"` // want `phnenv does not support fields of type chan int`
+	C [2]int            `phnenv:"C"` // want `phnenv does not support fields of type \[2\]int`
+	D [][]int           `phnenv:"D"` // want `phnenv does not support fields of type \[\]\[\]int`
+	E []nested          `phnenv:"E"` // want `phnenv does not support fields of type \[\]a.nested`
+	F interface{}       `phnenv:"F"` // want `phnenv does not support fields of type interface{}`
+	G func()            `phnenv:"G"` // want `phnenv does not support fields of type func\(\)`
+}
+
+type taggedStruct struct {
+	A nested  `phnenv:"A"` // want `phnenv tag on a struct field is ignored`
+	B *nested `phnenv:"B"` // want `phnenv tag on a struct field is ignored` `phnenv key NESTED_NAME of field B.Name is also read by field A.Name`
+}
+
+type duplicates struct {
+	A string `phnenv:"DUP"`
+	B string `phnenv:"DUP"` // want `phnenv key DUP of field B is also read by field A`
+	C string `phnenv:"NEW|OLD"`
+	D string `phnenv:"OLD"` // want `phnenv key OLD of field D is also read by field C`
+	E nestedDuplicate
+	F *nestedDuplicate // want `phnenv key NESTED_DUP of field F.Name is also read by field E.Name`
+}
+
+type nestedDuplicate struct {
+	Name string `phnenv:"NESTED_DUP"`
+}
+
+type node struct {
+	Val  string `phnenv:"NODE_VAL"`
+	Next *node
+}
+
+type tree struct {
+	Left, Right *tree
+	Parent      *node
+}
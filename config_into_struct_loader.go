@@ -3,9 +3,7 @@ package phnenv
 import (
 	"errors"
 	"fmt"
-	"os"
 	"reflect"
-	"strings"
 )
 
 const (
@@ -20,6 +18,9 @@ var (
 	errNumericOverflow = errors.New("environment value overflows numeric type")
 	errCantSet         = errors.New("can't set field")
 	errUnsupportedType = errors.New("unsupported field type")
+	errConflictingKeys = errors.New("aliased environment variables are set to different values")
+	errRequired        = errors.New("required environment variable is not set")
+	errSecretInvalid   = errors.New("invalid value for secret field, not shown")
 )
 
 // Function for getting a string value for a string key from a config source
@@ -27,8 +28,62 @@ var (
 // If it does not exist the bool result will be false.
 type confGetter func(string) (string, bool)
 
+// loader holds the state of a single call to Parse.
+type loader struct {
+	// srcs are the sources which config is read from, where earlier sources take precedence.
+	srcs []loadedSource
+
+	// credentials is true if fields are read from their credentials. The credentials belong to the current
+	// process, so they are only read when its environment is one of the sources.
+	credentials bool
+
+	opts options
+
+	// keys lists all keys in the config source. It is nil if the keys can't be listed.
+	keys func() []string
+
+	// knownKeys holds every key (including aliases) which is read by a field of the struct being parsed.
+	knownKeys map[string]bool
+
+	// report collects the problems with every field when Parse is called with WithAllErrors. It is nil otherwise.
+	report *Report
+
+	// consumed lists the keys which should be removed from the environment if parsing succeeds.
+	consumed []string
+}
+
+// fieldConf is the config loaded for a field.
+type fieldConf struct {
+	// key is the key which the config was found in, which may be one of the field's aliases.
+	key string
+
+	// raw is the config as it was found in the source, and value is the config after expansion.
+	raw   string
+	value string
+
+	// source is the name of the source the config was found in.
+	source string
+
+	// literal is true if the config must not be expanded, such as when it was read from a credential.
+	literal bool
+
+	// envKeys lists the variables which the config was read from in the process environment,
+	// including the variables its expansion refers to.
+	envKeys []string
+}
+
+// Function for parsing a config string into a field of a specific type which cannot be handled by its reflect.Kind alone.
+type typeSetter func(conf string, to tagOpts, fieldVal reflect.Value) error
+
+// typeSetters holds the parsing functions for types which are set as a single value rather than by their kind.
+// For example, net.IP is a []byte but is parsed from one IP address string, and netip.Addr is a struct
+// which must not be recursed into.
+var typeSetters = map[reflect.Type]typeSetter{}
+
 // Parse reads OS environment variables and fills the struct in the value pointed to by v.
 // If v is nil or not a pointer to a struct, Parse returns an error.
+// The behavior of Parse can be customized by passing Options such as WithExpansion.
+// Config values can be read from sources other than the OS environment using WithSources.
 //
 // Parse examines the tags on the fields of the struct pointed to by v in order to
 // determine which environment variables should be read for which field, and how
@@ -41,6 +96,10 @@ type confGetter func(string) (string, bool)
 //   uint, uint8, uint16, uint32, uint64
 //   float32, float64
 //   complex64, complex128
+//   net.IP, net.IPNet
+//   netip.Addr, netip.AddrPort, netip.Prefix
+//   phnenv.HostPort
+//   url.URL
 // In addition, pointers to and slices of the above types are supported.
 // Nested structs are supported.
 //
@@ -63,6 +122,16 @@ type confGetter func(string) (string, bool)
 // In the above example, the ENVIRONMENT_VAR variable will be parsed as a string
 // and the result set into s.FieldName.
 //
+// A field can be read from one of several environment variables by separating their names with "|".
+// This is useful when renaming a variable. The first listed variable takes precedence, and the
+// others are only used if it is not set. Parse returns an error if more than one of the variables is
+// set in the same source and their values differ. With several sources, the earliest source which holds
+// any of the variables is used. Use WithDeprecatedKeyHandler to be notified when an alias was used:
+//
+//   s := struct {
+//       FieldName string `phnenv:"NEW_NAME|OLD_NAME"`
+//   }{}
+//
 // Additional options for how to parse the environment variable can be added
 // to a field's tag if necessary.
 //
@@ -71,7 +140,17 @@ type confGetter func(string) (string, bool)
 //   rune
 //   bitsize:
 //   base:
+//   bytesize
 //   sep:
+//   absolute
+//   schemes:
+//   noexpand
+//   secret
+//   required
+//   flag:
+//   unset
+//   credential:
+//   desc:
 //
 // The `rune` parsing option can be applied to fields of type int32
 // (the standard Go rune type is an alias for int32, so you can also use the type rune).
@@ -111,6 +190,20 @@ type confGetter func(string) (string, bool)
 //      Field int `phnenv:"ENV_VAR,base:2"`
 //   }{}
 //
+// The `bytesize` option tells the parser to parse the environment variable as a human readable
+// amount of bytes, such as "512", "64MiB", "1.5GB" or "512k".
+// This option can be applied to fields of int and uint. Suffixes are matched ignoring case.
+// SI suffixes (k, KB, M, MB, G, GB, T, TB, P, PB, E, EB) are powers of 1000 and
+// IEC suffixes (Ki, KiB, Mi, MiB, Gi, GiB, Ti, TiB, Pi, PiB, Ei, EiB) are powers of 1024.
+// A fractional number is allowed as long as the result is a whole number of bytes.
+// The `bitsize:` option is still respected, but `base:` is ignored when `bytesize` is provided.
+// With `bytesize`, `bitsize:` must be between 0 and 64, and 0 means 64.
+// Example usage where the string environment variable ENV_VAR=64MiB is parsed to 67108864:
+//
+//   s struct {
+//      Field uint64 `phnenv:"ENV_VAR,bytesize"`
+//   }{}
+//
 // The `sep:` option specifies the string used to split a single environment variable into a
 // list of strings when parsing a slice type. This works the same as the sep argument to the
 // standard library strings.Split function. Please check the strings docs for more info.
@@ -126,6 +219,50 @@ type confGetter func(string) (string, bool)
 //
 //   phnenv.Parse(&s)
 //
+// The `absolute` option can be applied to fields of url.URL. It requires the URL to be absolute
+// (that is, to have a scheme).
+//
+// The `schemes:` option can be applied to fields of url.URL. It requires the URL to be absolute and
+// to have one of the listed schemes, which are separated by "|" and compared ignoring case.
+// Example usage where ENV_VAR must be an http or https URL:
+//
+//   s struct {
+//      Field *url.URL `phnenv:"ENV_VAR,schemes:https|http"`
+//   }{}
+//
+// The userinfo of URLs, which holds the user name and password, is replaced with "xxxxx" in the errors
+// returned for url.URL fields.
+//
+// The `noexpand` option disables variable interpolation for a field when Parse is called with WithExpansion.
+// This is useful for values which contain a literal "$", such as passwords.
+//
+// The `secret` option marks a field which holds a secret, such as a password or API key.
+// It does not change how the field is parsed, but its value is replaced with "xxxxx" in the reports
+// made by WithProvenance and in the Changes passed to Watcher.OnChange functions.
+//
+// The `required` option makes Parse return an error if none of the field's keys are set.
+//
+// The `desc:` option describes the field to the people configuring the program, for example
+// `phnenv:"PORT,required,desc:port to listen on"`. The description is shown in the errors reported by MustParse.
+// Because the description may contain commas, desc: must be the last option in the tag.
+//
+// The `flag:` option sets the name of the command line flag defined for the field by BindFlags.
+//
+// The `unset` option removes the environment variables the field was read from, including the variables its
+// expansion refers to, from the process environment after the whole struct has been parsed successfully.
+// See WithUnset.
+//
+// The `credential:` option reads the field from the systemd credential with the given name, which is a file in
+// the directory named by $CREDENTIALS_DIRECTORY. If the credential exists, it takes precedence over the field's keys
+// in every source except flags bound with BindFlags, so secrets do not need to be put in the environment.
+// Otherwise the field's keys are read as usual. Credentials are only read when Env() is one of the sources
+// (as it is by default), because they belong to the current process.
+// Line breaks at the end of the credential are removed, and it is not expanded by WithExpansion. For example:
+//
+//   s := struct {
+//      Password string `phnenv:"DB_PASSWORD,credential:db-password,secret"`
+//   }{}
+//
 // Brief overview of how parsing works for each type:
 //
 //   string: copied directly from the environment variable
@@ -134,6 +271,11 @@ type confGetter func(string) (string, bool)
 //   float: parsed using strconv.ParseFloat
 //   complex: parsed using strconv.ParseComplex
 //   bool: if the environment variable's string equals (ignoring case) "true" then the bool will be true
+//   net.IP: parsed using net.ParseIP
+//   net.IPNet: parsed using net.ParseCIDR (the IP address is masked to the network address)
+//   netip.Addr, netip.AddrPort, netip.Prefix: parsed using netip.ParseAddr, netip.ParseAddrPort and netip.ParsePrefix
+//   phnenv.HostPort: split using net.SplitHostPort. The port must be numeric
+//   url.URL: parsed using url.Parse
 //   slices: the environment variable's string will be split with strings.Split using a configurable separator. Then, each index will be parsed individually as the slice element type.
 //
 // Errors will be returned by Parse in the following cases:
@@ -141,8 +283,8 @@ type confGetter func(string) (string, bool)
 //    2. One or more struct tags is malformed or invalid.
 //    3. The input v is not a pointer to a struct.
 //    4. A phnenv struct tag was placed on a struct field of an unsupported type.
-func Parse(v interface{}) error {
-	err := parse(os.LookupEnv, v)
+func Parse(v interface{}, opts ...Option) error {
+	err := parseSources(v, newOptions(opts))
 	if err != nil {
 		return fmt.Errorf(errWrapFmt, err)
 	}
@@ -150,13 +292,64 @@ func Parse(v interface{}) error {
 	return nil
 }
 
-func parse(c confGetter, v interface{}) error {
+func parseSources(v interface{}, o options) error {
+	ls, err := loadSources(o.sources)
+	if err != nil {
+		return err
+	}
+
+	return parseWithLoader(newLoader(ls, ls.keys, o), v)
+}
+
+func parse(c confGetter, v interface{}, opts ...Option) error {
+	ls := &loadedSources{srcs: []loadedSource{{lookup: c}}}
+
+	return parseWithLoader(newLoader(ls, nil, newOptions(opts)), v)
+}
+
+func newLoader(ls *loadedSources, keys func() []string, o options) *loader {
+	ld := &loader{srcs: ls.srcs, opts: o, keys: keys}
+	for _, src := range ls.srcs {
+		ld.credentials = ld.credentials || src.env
+	}
+
+	return ld
+}
+
+func parseWithLoader(ld *loader, v interface{}) error {
 	sv, err := validateInput(v)
 	if err != nil {
 		return err
 	}
 
-	return iterateStruct(c, sv)
+	sp, err := planFor(sv.Type())
+	if err != nil {
+		return err
+	}
+
+	ld.knownKeys = sp.keys
+	resetProvenance(ld)
+	if ld.opts.allErrors {
+		ld.report = &Report{}
+	}
+
+	err = executePlan(ld, sp, sv, "")
+	if err != nil {
+		return err
+	}
+
+	err = checkUnknownKeys(ld)
+	if err != nil {
+		return err
+	}
+
+	if ld.report != nil && !ld.report.empty() {
+		return ld.report
+	}
+
+	unsetConsumed(ld.consumed)
+
+	return nil
 }
 
 func validateInput(v interface{}) (reflect.Value, error) {
@@ -175,60 +368,85 @@ func validateInput(v interface{}) (reflect.Value, error) {
 	return res, nil
 }
 
-func iterateStruct(c confGetter, sv reflect.Value) error {
-	for i := 0; i < sv.NumField(); i++ {
-		err := loadConfAndSetField(c, sv.Type().Field(i), sv.Field(i))
+// executePlan fills the fields of the struct sv. The path of each field is its name following prefix.
+func executePlan(ld *loader, sp *structPlan, sv reflect.Value, prefix string) error {
+	for _, fp := range sp.fields {
+		err := loadConfAndSetField(ld, fp, sv.Field(fp.index), prefix+fp.name)
 		if err != nil {
-			return fmt.Errorf(fieldWrapFmt, sv.Type().Field(i).Name, err)
+			return fmt.Errorf(fieldWrapFmt, fp.name, err)
 		}
 	}
 
 	return nil
 }
 
-func iterateStructPtr(c confGetter, fv reflect.Value) error {
-	if fv.IsNil() {
-		newPtr := reflect.New(fv.Type().Elem())
-		fv.Set(newPtr)
-	}
-
-	if fv.Type().Elem().Kind() == reflect.Ptr {
-		return iterateStructPtr(c, reflect.Indirect(fv))
+func loadConfAndSetField(ld *loader, fp *fieldPlan, fv reflect.Value, path string) error {
+	if fp.nested != nil {
+		return executePlan(ld, fp.nested, allocStructPtrs(fv), path+".")
 	}
 
-	err := iterateStruct(c, reflect.Indirect(fv))
-	if err != nil {
-		return err
+	err := loadConfAndSetValue(ld, fp, fv, path)
+	if err != nil && ld.report != nil {
+		ld.report.addField(path, fp, fv.Type(), err)
+		return nil
 	}
 
-	return nil
+	return err
 }
 
-func loadConfAndSetField(c confGetter, sf reflect.StructField, fv reflect.Value) error {
-	if isStruct(fv.Type()) {
-		return iterateStruct(c, fv)
-	}
-	if isStructPtr(fv.Type()) {
-		return iterateStructPtr(c, fv)
-	}
-
-	conf, to, ok, err := parseStructTagAndLoadConf(c, sf)
+func loadConfAndSetValue(ld *loader, fp *fieldPlan, fv reflect.Value, path string) error {
+	fc, ok, err := loadAndExpandConf(ld, fp)
 	if err != nil {
 		return err
 	}
 	if !ok {
+		if fp.opts.Required {
+			return errRequired
+		}
+
+		recordProvenance(ld, path, fp, nil, fv)
 		return nil
 	}
 
-	err = setField(conf, to, fv)
+	if !fv.CanSet() {
+		return errCantSet
+	}
+
+	err = fp.set(fc.value, fv)
+	if err != nil && fp.opts.Secret {
+		// parsing errors usually quote the value, which must not be printed for a secret field
+		return errSecretInvalid
+	}
 	if err != nil {
 		return err
 	}
 
+	recordProvenance(ld, path, fp, &fc, fv)
+	if ld.opts.unset || fp.opts.Unset {
+		ld.consumed = append(ld.consumed, fc.envKeys...)
+	}
+
 	return nil
 }
 
+// allocStructPtrs follows the pointers from fv to the struct they point to, allocating any pointers which are nil.
+func allocStructPtrs(fv reflect.Value) reflect.Value {
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+
+		fv = fv.Elem()
+	}
+
+	return fv
+}
+
 func isStructPtr(ft reflect.Type) bool {
+	if hasTypeSetter(ft) {
+		return false
+	}
+
 	if ft.Kind() == reflect.Ptr {
 		return isStructPtr(ft.Elem())
 	}
@@ -237,54 +455,137 @@ func isStructPtr(ft reflect.Type) bool {
 }
 
 func isStruct(ft reflect.Type) bool {
-	return ft.Kind() == reflect.Struct
+	return ft.Kind() == reflect.Struct && !hasTypeSetter(ft)
 }
 
-func parseStructTagAndLoadConf(c confGetter, sf reflect.StructField) (string, tagOpts, bool, error) {
-	tagStr, ok := sf.Tag.Lookup(phnEnvStructTag)
-	if !ok {
-		return "", tagOpts{}, false, nil // if there's no phnenv tag this is not an error, but we should skip this field
+func hasTypeSetter(ft reflect.Type) bool {
+	_, ok := typeSetters[ft]
+
+	return ok
+}
+
+// loadAndExpandConf gets the config for a field from its credential or its keys, if it exists,
+// and expands any variable references in it.
+func loadAndExpandConf(ld *loader, fp *fieldPlan) (fieldConf, bool, error) {
+	fc, ok, err := loadConf(ld, fp)
+	if err != nil || !ok {
+		return fieldConf{}, false, err
 	}
 
-	key, opts, err := parseTag(tagStr)
-	if err != nil {
-		return "", opts, false, err
+	if ld.opts.expand && !fp.opts.NoExpand && !fc.literal {
+		fc.value, err = expand(fc.key, fc.raw, ld.envRecordingLookup(&fc.envKeys))
+		if err != nil {
+			return fieldConf{}, false, err
+		}
 	}
 
-	conf, ok := c(key)
+	return fc, true, nil
+}
+
+// loadConf gets the config for a field's key from the first source which holds the key or any of its aliases.
+// Within that source, it falls back to the aliases in order if the key is not set, so a key in an earlier
+// source takes precedence over its aliases in later sources.
+// It is an error for the key and its aliases to be set to different values in the same source.
+//
+// The field's credential, if it has one, is read after any flags sources and before every other source,
+// as long as the process environment is one of the sources.
+func loadConf(ld *loader, fp *fieldPlan) (fieldConf, bool, error) {
+	key, aliases := fp.key, fp.opts.Aliases
+	credentialRead := fp.opts.Credential == "" || !ld.credentials
+
+	for _, src := range ld.srcs {
+		if !credentialRead && !src.flags {
+			credentialRead = true
+
+			fc, ok, err := loadCredential(fp.opts.Credential)
+			if err != nil || ok {
+				return fc, ok, err
+			}
+		}
+
+		foundKey, conf, setKeys, err := loadConfFrom(src, key, aliases)
+		if err != nil {
+			return fieldConf{}, false, err
+		}
+		if len(setKeys) < 1 {
+			continue
+		}
+
+		if foundKey != key && ld.opts.onDeprecatedKey != nil {
+			ld.opts.onDeprecatedKey(foundKey, key)
+		}
+
+		fc := fieldConf{key: foundKey, raw: conf, value: conf, source: src.name}
+		if src.env {
+			fc.envKeys = setKeys
+		}
+
+		return fc, true, nil
+	}
 
-	return conf, opts, ok, nil
+	return fieldConf{}, false, nil
 }
 
-func setField(conf string, to tagOpts, fieldVal reflect.Value) error {
-	if !fieldVal.CanSet() {
-		return errCantSet
+// loadCredential gets the config for a field from the named credential, if it exists.
+func loadCredential(name string) (fieldConf, bool, error) {
+	conf, ok, err := readCredential(name)
+	if err != nil || !ok {
+		return fieldConf{}, false, err
 	}
 
-	switch fieldVal.Kind() {
-	case reflect.Bool:
-		setBasicBool(conf, fieldVal)
-	case reflect.String:
-		setBasicStr(conf, fieldVal)
-	case reflect.Int32:
-		return setBasicInt32(conf, to, fieldVal)
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int64:
-		return setBasicInt(conf, to, fieldVal)
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return setBasicUint(conf, to, fieldVal)
-	case reflect.Float32, reflect.Float64:
-		return setBasicFloat(conf, to, fieldVal)
-	case reflect.Complex64, reflect.Complex128:
-		return setBasicComplex(conf, to, fieldVal)
-	case reflect.Ptr:
-		return setPtr(conf, to, fieldVal)
-	case reflect.Slice:
-		return setSlice(conf, to, fieldVal)
-	default:
-		return errUnsupportedType
+	// credentials hold secrets, which are taken literally rather than expanded
+	return fieldConf{key: name, raw: conf, value: conf, source: credentialsSourceName, literal: true}, true, nil
+}
+
+// loadConfFrom gets the config for a field's key from a single source, falling back to its aliases in order.
+// The string results are the key which was found and its config. The slice result lists every one of the key
+// and its aliases which is set in the source, and is empty if none of them are.
+func loadConfFrom(src loadedSource, key string, aliases []string) (string, string, []string, error) {
+	conf, ok := src.lookup(key)
+	foundKey := key
+
+	var setKeys []string
+	if ok {
+		setKeys = append(setKeys, key)
 	}
 
-	return nil
+	for _, alias := range aliases {
+		aliasConf, aliasOK := src.lookup(alias)
+		if !aliasOK {
+			continue
+		}
+
+		setKeys = append(setKeys, alias)
+
+		if !ok {
+			conf, ok, foundKey = aliasConf, true, alias
+			continue
+		}
+
+		if aliasConf != conf {
+			return "", "", nil, fmt.Errorf("%w: %s and %s", errConflictingKeys, foundKey, alias)
+		}
+	}
+
+	return foundKey, conf, setKeys, nil
+}
+
+// envRecordingLookup returns a confGetter which gets keys from the first source which holds them,
+// and appends the keys it reads from the process environment to envKeys.
+func (ld *loader) envRecordingLookup(envKeys *[]string) confGetter {
+	return func(key string) (string, bool) {
+		for _, src := range ld.srcs {
+			if v, ok := src.lookup(key); ok {
+				if src.env {
+					*envKeys = append(*envKeys, key)
+				}
+
+				return v, true
+			}
+		}
+
+		return "", false
+	}
 }
 
 func setBasicStr(conf string, fieldVal reflect.Value) {
@@ -296,7 +597,7 @@ func setBasicBool(conf string, fieldVal reflect.Value) {
 }
 
 func setBasicInt(conf string, to tagOpts, fieldVal reflect.Value) error {
-	v, err := strToInt(conf, to.NumBitSize, to.NumBase)
+	v, err := strToInt(conf, to.NumBitSize, to.NumBase, to.IsByteSize)
 	if err != nil {
 		return err
 	}
@@ -330,7 +631,7 @@ func setBasicInt32(conf string, to tagOpts, fieldVal reflect.Value) error {
 }
 
 func setBasicUint(conf string, to tagOpts, fieldVal reflect.Value) error {
-	v, err := strToUint(conf, to.NumBitSize, to.NumBase)
+	v, err := strToUint(conf, to.NumBitSize, to.NumBase, to.IsByteSize)
 	if err != nil {
 		return err
 	}
@@ -373,40 +674,3 @@ func setBasicComplex(conf string, to tagOpts, fieldVal reflect.Value) error {
 
 	return nil
 }
-
-func setSlice(conf string, to tagOpts, fv reflect.Value) error {
-	if fv.Type().Elem().Kind() == reflect.Slice {
-		return errUnsupportedType
-	}
-
-	var splt []string
-	if len(conf) > 0 {
-		splt = strings.Split(conf, to.SliceSep)
-	}
-
-	res := reflect.MakeSlice(fv.Type(), len(splt), len(splt))
-
-	for i := 0; i < len(splt); i++ {
-		err := setField(splt[i], to, res.Index(i))
-		if err != nil {
-			return err
-		}
-	}
-
-	fv.Set(res)
-
-	return nil
-}
-
-func setPtr(conf string, to tagOpts, fieldVal reflect.Value) error {
-	newPtr := reflect.New(fieldVal.Type().Elem())
-
-	err := setField(conf, to, reflect.Indirect(newPtr))
-	if err != nil {
-		return err
-	}
-
-	fieldVal.Set(newPtr)
-
-	return nil
-}
@@ -191,6 +191,30 @@ var test_parse_TagOpts_PositiveCases = []struct {
 		&struct {
 			F []int8 `phnenv:"TESTENV,sep:||,base:2,bitsize:8"`
 		}{F: []int8{2, 5}}},
+	{"int bytesize",
+		&struct {
+			F int `phnenv:"TESTENV,bytesize"`
+		}{},
+		"1.5GB",
+		&struct {
+			F int `phnenv:"TESTENV,bytesize"`
+		}{F: 1500000000}},
+	{"uint bytesize",
+		&struct {
+			F uint `phnenv:"TESTENV,bytesize"`
+		}{},
+		"64MiB",
+		&struct {
+			F uint `phnenv:"TESTENV,bytesize"`
+		}{F: 64 << 20}},
+	{"uint64 slice bytesize",
+		&struct {
+			F []uint64 `phnenv:"TESTENV,bytesize"`
+		}{},
+		"512k,2Ki,7",
+		&struct {
+			F []uint64 `phnenv:"TESTENV,bytesize"`
+		}{F: []uint64{512000, 2048, 7}}},
 	{"ignore irrelevant tags - float",
 		&struct {
 			F float64 `phnenv:"TESTENV,rune,base:2"`
@@ -271,12 +295,42 @@ var test_parse_TagOpts_NegativeCases = []struct {
 		}{},
 		"10",
 		"bitsize option must only be provided once"},
+	{"bytesize with bitsize above 64",
+		&struct {
+			F int64 `phnenv:"E,bytesize,bitsize:128"`
+		}{},
+		"100EiB",
+		"bitsize option must be between 0 and 64 when bytesize is provided"},
+	{"bytesize with negative bitsize",
+		&struct {
+			F uint64 `phnenv:"E,bytesize,bitsize:-1"`
+		}{},
+		"1KiB",
+		"bitsize option must be between 0 and 64 when bytesize is provided"},
 	{"duplicate sep",
 		&struct {
 			F int `phnenv:"E,sep:8,sep:8"`
 		}{},
 		"10",
 		"sep option must only be provided once"},
+	{"duplicate bytesize",
+		&struct {
+			F int `phnenv:"E,bytesize,bytesize"`
+		}{},
+		"10",
+		"bytesize option must only be provided once"},
+	{"empty alias",
+		&struct {
+			F int `phnenv:"E||OLD_E"`
+		}{},
+		"10",
+		"names must not be empty"},
+	{"trailing alias separator",
+		&struct {
+			F int `phnenv:"E|,base:2"`
+		}{},
+		"10",
+		"names must not be empty"},
 	{"empty bitsize",
 		&struct {
 			F int `phnenv:"E,bitsize:"`
@@ -924,6 +978,26 @@ var test_parse_EnvVarExistsButIsInvalid_ShouldReturnError = []struct {
 			F uint64 `phnenv:"TESTENV"`
 		}{},
 		"99999999999999999999999"},
+	{"int bytesize",
+		&struct {
+			F int `phnenv:"TESTENV,bytesize"`
+		}{},
+		"12 parsecs"},
+	{"int8 bytesize overflow",
+		&struct {
+			F int8 `phnenv:"TESTENV,bytesize"`
+		}{},
+		"1k"},
+	{"int bytesize bitsize overflow",
+		&struct {
+			F int `phnenv:"TESTENV,bytesize,bitsize:16"`
+		}{},
+		"1MiB"},
+	{"uint bytesize negative",
+		&struct {
+			F uint `phnenv:"TESTENV,bytesize"`
+		}{},
+		"-1k"},
 	{"float32",
 		&struct {
 			F float32 `phnenv:"TESTENV"`
@@ -1005,3 +1079,95 @@ func Test_parse_EnvVarExistsButIsInvalid_ShouldReturnError(t *testing.T) {
 		})
 	}
 }
+
+var test_parse_KeyAliases = []struct {
+	Name               string
+	Conf               map[string]string
+	Expected           string
+	ExpectedDeprecated []string
+}{
+	{"only new key", map[string]string{"NEW": "a"}, "a", nil},
+	{"only old key", map[string]string{"OLD": "b"}, "b", []string{"OLD", "NEW"}},
+	{"only oldest key", map[string]string{"OLDEST": "c"}, "c", []string{"OLDEST", "NEW"}},
+	{"old keys in precedence order", map[string]string{"OLD": "b", "OLDEST": "b"}, "b", []string{"OLD", "NEW"}},
+	{"all keys same value", map[string]string{"NEW": "a", "OLD": "a", "OLDEST": "a"}, "a", nil},
+	{"no keys", map[string]string{}, "default", nil},
+}
+
+func Test_parse_KeyAliases(t *testing.T) {
+	for _, c := range test_parse_KeyAliases {
+		t.Run(c.Name, func(t *testing.T) {
+			s := struct {
+				F string `phnenv:"NEW|OLD|OLDEST"`
+			}{F: "default"}
+			var deprecated []string
+
+			err := parse(mapGetter(c.Conf), &s, WithDeprecatedKeyHandler(func(deprecatedKey string, key string) {
+				deprecated = append(deprecated, deprecatedKey, key)
+			}))
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, c.Expected, s.F)
+				assert.Equal(t, c.ExpectedDeprecated, deprecated)
+			}
+		})
+	}
+}
+
+func Test_parse_KeyAliasesWithConflictingValues_ReturnsError(t *testing.T) {
+	s := struct {
+		F string `phnenv:"NEW|OLD|OLDEST"`
+	}{}
+
+	err := parse(mapGetter(map[string]string{"OLD": "a", "OLDEST": "b"}), &s)
+
+	if assert.True(t, errors.Is(err, errConflictingKeys)) {
+		assert.Contains(t, err.Error(), "OLD and OLDEST")
+	}
+}
+
+func Test_Parse_KeyAliasesInDifferentSources_EarliestSourceWins(t *testing.T) {
+	s := struct {
+		F string `phnenv:"NEW|OLD"`
+	}{}
+	var deprecated []string
+
+	err := Parse(&s, WithSources(
+		Map("first", map[string]string{"NEW": "a"}),
+		Map("second", map[string]string{"OLD": "b"}),
+	), WithDeprecatedKeyHandler(func(deprecatedKey string, key string) {
+		deprecated = append(deprecated, deprecatedKey, key)
+	}))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a", s.F)
+		assert.Nil(t, deprecated)
+	}
+}
+
+func Test_Parse_AliasInEarlierSource_TakesPrecedenceOverKeyInLaterSource(t *testing.T) {
+	s := struct {
+		F string `phnenv:"NEW|OLD"`
+	}{}
+
+	err := Parse(&s, WithSources(
+		Map("first", map[string]string{"OLD": "b"}),
+		Map("second", map[string]string{"NEW": "a"}),
+	))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "b", s.F)
+	}
+}
+
+func Test_parse_KeyAliasesWithoutHandler_DoesNotPanic(t *testing.T) {
+	s := struct {
+		F []int `phnenv:"NEW|OLD,base:2"`
+	}{}
+
+	err := parse(mapGetter(map[string]string{"OLD": "10,11"}), &s)
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []int{2, 3}, s.F)
+	}
+}
@@ -0,0 +1,215 @@
+package phnenv
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	consulKVPath = "/v1/kv/"
+
+	headerConsulToken = "X-Consul-Token"
+	headerConsulIndex = "X-Consul-Index"
+
+	// consulWait is how long a blocking query waits for a change. Consul adds up to 1/16 of it as jitter.
+	consulWait = 5 * time.Minute
+
+	// defaultConsulWatchDelay is how long OnConsulChange waits after a failed query, unless set by WithRetry.
+	defaultConsulWatchDelay = 5 * time.Second
+)
+
+var (
+	errConsulValue = errors.New("value is not valid base64")
+	errConsulIndex = errors.New("invalid " + headerConsulIndex + " header")
+)
+
+// consulEntry is an entry in the response of Consul's KV API. Value is base64 encoded, and is nil for empty values.
+type consulEntry struct {
+	Key   string
+	Value *string
+}
+
+// Consul returns a Source containing the keys under prefix in the KV store of the Consul agent at addr,
+// such as "http://127.0.0.1:8500". The keys are fetched with a single recursive request every time
+// the source is loaded, using Consul's HTTP API.
+//
+// prefix is treated as a folder, so the prefix "app" contains app/db/host but not apple/db/host.
+// The rest of each key is split on "/" and flattened in the same way as for JSONFile, so app/db/host
+// has the key DB_HOST. This can be changed with WithResponseKeys. An empty prefix fetches the whole store.
+// Folders are skipped, and it is not an error if there are no keys under prefix.
+//
+// An ACL token can be sent with WithToken. Requests can also be configured with WithContext, WithHTTPClient,
+// WithTimeout, WithRetry and WithMaxResponseSize. To reload config when the keys change, use OnConsulChange.
+func Consul(addr string, prefix string, opts ...HTTPOption) Source {
+	return consulSource{url: consulURL(addr, prefix), prefix: consulPrefix(prefix), opts: newHTTPOptions(opts)}
+}
+
+type consulSource struct {
+	url    string
+	prefix string
+	opts   httpOptions
+}
+
+func (cs consulSource) Name() string {
+	return redactURLString(cs.url)
+}
+
+func (cs consulSource) Load() (map[string]string, error) {
+	resp, err := doRequest(cs.opts, cs.url+"?recurse=true", consulHeader(cs.opts))
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.status {
+	case http.StatusOK:
+		return parseConsulKV(resp.body, cs.prefix, cs.opts.flatten)
+	case http.StatusNotFound:
+		return map[string]string{}, nil
+	default:
+		return nil, statusError(resp)
+	}
+}
+
+// OnConsulChange returns a Trigger which reloads the config when any key under prefix in the KV store of
+// the Consul agent at addr is changed, added or removed. It uses blocking queries, so changes are seen
+// as soon as Consul makes them, without polling.
+//
+// The options should be the same as those passed to Consul, except WithContext which is replaced by
+// the context passed to the Trigger. WithTimeout is added to the time Consul may wait for a change.
+// After a failed query, the next query is made after the delay set by WithRetry, or 5 seconds by default.
+func OnConsulChange(addr string, prefix string, opts ...HTTPOption) Trigger {
+	o := newHTTPOptions(opts)
+	o.timeout += consulWait + consulWait/16
+
+	delay := o.retryDelay
+	if delay <= 0 {
+		delay = defaultConsulWatchDelay
+	}
+
+	u := consulURL(addr, prefix)
+	header := consulHeader(o)
+
+	return func(ctx context.Context, reload func()) {
+		o := o
+		o.ctx = ctx
+
+		var index uint64
+		for ctx.Err() == nil {
+			next, err := consulIndex(o, u, header, index)
+			if err != nil {
+				select {
+				case <-ctx.Done():
+				case <-time.After(delay):
+				}
+
+				continue
+			}
+
+			if index != 0 && next != index {
+				reload()
+			}
+
+			// the index can go backwards, such as when a snapshot is restored, so it is replaced rather than
+			// compared. It must be at least 1, or the next query would return immediately instead of blocking.
+			index = next
+			if index < 1 {
+				index = 1
+			}
+		}
+	}
+}
+
+// consulIndex makes a blocking query for the keys at u, which returns the index of the result once it is
+// different from index or consulWait has passed. If index is 0, the current index is returned immediately.
+func consulIndex(o httpOptions, u string, header http.Header, index uint64) (uint64, error) {
+	query := url.Values{"recurse": {"true"}, "index": {strconv.FormatUint(index, 10)}, "wait": {consulWait.String()}}
+
+	resp, err := doAttempt(o, u+"?"+query.Encode(), header)
+	if err != nil {
+		return 0, err
+	}
+	if resp.status != http.StatusOK && resp.status != http.StatusNotFound {
+		return 0, statusError(resp)
+	}
+
+	next, err := strconv.ParseUint(resp.header.Get(headerConsulIndex), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", errConsulIndex, resp.header.Get(headerConsulIndex))
+	}
+
+	return next, nil
+}
+
+// consulPrefix returns prefix as a folder path, without a leading "/" and with a trailing "/" unless it is empty.
+func consulPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+
+	return prefix + "/"
+}
+
+func consulURL(addr string, prefix string) string {
+	parts := strings.Split(consulPrefix(prefix), "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+
+	return strings.TrimRight(addr, "/") + consulKVPath + strings.Join(parts, "/")
+}
+
+func consulHeader(o httpOptions) http.Header {
+	header := http.Header{}
+	if o.token != "" {
+		header.Set(headerConsulToken, o.token)
+	}
+
+	return header
+}
+
+func parseConsulKV(b []byte, prefix string, opts []FlattenOption) (map[string]string, error) {
+	var entries []consulEntry
+	err := json.Unmarshal(b, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	f := newFlattener(opts)
+
+	for _, e := range entries {
+		if strings.HasSuffix(e.Key, "/") || !strings.HasPrefix(e.Key, prefix) {
+			continue
+		}
+
+		val := ""
+		if e.Value != nil {
+			decoded, err := base64.StdEncoding.DecodeString(*e.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", errConsulValue, e.Key)
+			}
+
+			val = string(decoded)
+		}
+
+		key := ""
+		for _, part := range strings.Split(strings.TrimPrefix(e.Key, prefix), "/") {
+			key = f.key(key, part)
+		}
+
+		err = f.set(key, val)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f.res, nil
+}
@@ -0,0 +1,62 @@
+package phnenv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// credentialsDirEnv is the environment variable in which systemd passes the directory holding a service's credentials.
+	credentialsDirEnv = "CREDENTIALS_DIRECTORY"
+
+	credentialsSourceName = "credentials"
+)
+
+// Credentials returns a Source containing the credentials passed to a systemd service with options such as
+// LoadCredential= and SetCredential=. systemd writes each credential to a file in the directory named by
+// $CREDENTIALS_DIRECTORY, and the source reads the files in the same way as Dir, so the credential db-password
+// has the key DB_PASSWORD. If $CREDENTIALS_DIRECTORY is not set, the source is empty.
+//
+// To read a single credential into a field without adding the source, use the credential: tag option.
+func Credentials(opts ...DirOption) Source {
+	return credentialsSource{opts: newDirOptions(opts)}
+}
+
+type credentialsSource struct {
+	opts dirOptions
+}
+
+func (credentialsSource) Name() string {
+	return credentialsSourceName
+}
+
+func (cs credentialsSource) Load() (map[string]string, error) {
+	dir := os.Getenv(credentialsDirEnv)
+	if dir == "" {
+		return map[string]string{}, nil
+	}
+
+	return readDir(nil, dir, cs.opts)
+}
+
+// readCredential reads the systemd credential with the given name. The bool result is false if
+// $CREDENTIALS_DIRECTORY is not set or the credential does not exist.
+func readCredential(name string) (string, bool, error) {
+	dir := os.Getenv(credentialsDirEnv)
+	if dir == "" {
+		return "", false, nil
+	}
+
+	val, err := readFileLimited(nil, filepath.Join(dir, name), defaultMaxFileSize)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(val, "\r\n"), true, nil
+}
@@ -0,0 +1,144 @@
+package phnenv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Credentials(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-password": "hunter2\n", "api.token": "abc"})
+	t.Setenv(credentialsDirEnv, dir)
+
+	res, err := Credentials().Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"DB_PASSWORD": "hunter2", "API_TOKEN": "abc"}, res)
+	}
+}
+
+func Test_Credentials_NoDirectory(t *testing.T) {
+	t.Setenv(credentialsDirEnv, "")
+
+	res, err := Credentials().Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{}, res)
+	}
+}
+
+func Test_Parse_Credential(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-password": "cred-$pass\n"})
+	t.Setenv(credentialsDirEnv, dir)
+	t.Setenv("DB_PASSWORD", "env-pass")
+	t.Setenv("API_TOKEN", "env-token")
+	s := struct {
+		Password string `phnenv:"DB_PASSWORD,credential:db-password,secret"`
+		Token    string `phnenv:"API_TOKEN,credential:api-token"`
+	}{}
+	var report []Provenance
+
+	err := Parse(&s, WithExpansion(), WithProvenance(&report))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "cred-$pass", s.Password)
+		assert.Equal(t, "env-token", s.Token)
+		assert.Equal(t, []Provenance{
+			{Field: "Password", Key: "db-password", Source: "credentials", Value: "xxxxx"},
+			{Field: "Token", Key: "API_TOKEN", Source: "env", Value: "env-token"},
+		}, report)
+	}
+}
+
+func Test_Parse_Credential_SatisfiesRequired(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-password": "hunter2"})
+	t.Setenv(credentialsDirEnv, dir)
+	s := struct {
+		Password string `phnenv:"PHNENV_TEST_UNSET_DB_PASSWORD,credential:db-password,required"`
+	}{}
+
+	err := Parse(&s, WithSources(Env()))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hunter2", s.Password)
+	}
+}
+
+func Test_Parse_Credential_NoDirectory(t *testing.T) {
+	t.Setenv(credentialsDirEnv, "")
+	t.Setenv("DB_PASSWORD", "env-pass")
+	s := struct {
+		Password string `phnenv:"DB_PASSWORD,credential:db-password"`
+	}{}
+
+	err := Parse(&s)
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "env-pass", s.Password)
+	}
+}
+
+func Test_Parse_Credential_Unreadable(t *testing.T) {
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "db-password"), 0700)
+	if !assert.Nil(t, err) {
+		return
+	}
+	t.Setenv(credentialsDirEnv, dir)
+	s := struct {
+		Password string `phnenv:"DB_PASSWORD,credential:db-password"`
+	}{}
+
+	err = Parse(&s)
+
+	assert.NotNil(t, err)
+}
+
+func Test_Parse_Credential_FlagsTakePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-password": "cred-pass", "db-user": "cred-user"})
+	t.Setenv(credentialsDirEnv, dir)
+	t.Setenv("DB_PASSWORD", "env-pass")
+	t.Setenv("DB_USER", "env-user")
+	s := struct {
+		Password string `phnenv:"DB_PASSWORD,credential:db-password"`
+		User     string `phnenv:"DB_USER,credential:db-user"`
+	}{}
+	fs := newTestFlagSet()
+	flags, err := BindFlags(fs, &s)
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = fs.Parse([]string{"-db-password", "flag-pass"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = Parse(&s, WithSources(flags, Env()))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "flag-pass", s.Password)
+		assert.Equal(t, "cred-user", s.User)
+	}
+}
+
+func Test_Parse_Credential_WithoutEnvSource_NotRead(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-password": "cred-pass", "db-user": "cred-user"})
+	t.Setenv(credentialsDirEnv, dir)
+	s := struct {
+		Password string `phnenv:"DB_PASSWORD,credential:db-password"`
+		User     string `phnenv:"DB_USER,credential:db-user"`
+	}{}
+
+	err := Parse(&s, WithSources(Map("m", map[string]string{"DB_PASSWORD": "map-pass"})))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "map-pass", s.Password)
+		assert.Equal(t, "", s.User)
+	}
+}
@@ -0,0 +1,221 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultMaxFileSize is the default size limit for the files read by Dir, which is the most data a
+	// Kubernetes ConfigMap or Secret can hold.
+	defaultMaxFileSize = 1 << 20
+
+	// kubernetesDataDir is the symlink to the current version of the files in a volume mounted by Kubernetes.
+	kubernetesDataDir = "..data"
+
+	// kubernetesHiddenPrefix starts the names of the files Kubernetes uses to update a volume.
+	kubernetesHiddenPrefix = ".."
+
+	dirSourceNameSep = ","
+)
+
+var errFileTooLarge = errors.New("file is larger than the size limit")
+
+// DirOption configures a Source created by Dir, Dirs, DirFS or DirsFS.
+type DirOption func(*dirOptions)
+
+type dirOptions struct {
+	keyFunc      func(name string) string
+	keepNewlines bool
+	maxFileSize  int64
+}
+
+func newDirOptions(opts []DirOption) dirOptions {
+	o := dirOptions{keyFunc: fileKey, maxFileSize: defaultMaxFileSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithFileKeyFunc sets the function which converts the name of a file to its key.
+// If the function returns "", the file is skipped. By default, names are converted to upper case
+// and "-" and "." are replaced with "_", so the file db-host has the key DB_HOST.
+func WithFileKeyFunc(f func(name string) string) DirOption {
+	return func(o *dirOptions) {
+		o.keyFunc = f
+	}
+}
+
+// WithKeepNewlines keeps the line breaks at the end of files, which are removed by default.
+func WithKeepNewlines() DirOption {
+	return func(o *dirOptions) {
+		o.keepNewlines = true
+	}
+}
+
+// WithMaxFileSize sets the size limit in bytes for each file. Loading the source fails if a file is larger than the limit.
+// The default is 1MiB.
+func WithMaxFileSize(n int64) DirOption {
+	return func(o *dirOptions) {
+		o.maxFileSize = n
+	}
+}
+
+// Dir returns a Source containing one value for each file in the directory at path, such as a Kubernetes
+// ConfigMap or Secret mounted as a volume. Each file's name is its key and its contents are its value.
+// The directory is read every time the source is loaded.
+//
+// Subdirectories are skipped, and symlinks are followed. Kubernetes updates a mounted volume by writing
+// a new hidden directory and switching the ..data symlink to it, so if the directory has a ..data entry,
+// the files are read from its target instead. This means all of the values come from the same version of the volume,
+// even if it is updated while they are being read. Other entries whose names start with ".." are skipped.
+func Dir(path string, opts ...DirOption) Source {
+	return Dirs([]string{path}, opts...)
+}
+
+// Dirs returns a Source like Dir which reads several directories. If a file with the same key is in more than one
+// directory, the value from the earliest directory in the list is used.
+func Dirs(paths []string, opts ...DirOption) Source {
+	return dirSource{paths: paths, opts: newDirOptions(opts)}
+}
+
+// DirFS returns a Source like Dir which reads the directory at path in fsys, such as an embed.FS.
+// Symlinks are followed if fsys supports them, but since an fs.FS cannot tell where a symlink leads,
+// a ..data symlink is only used as a directory to read the files from, which does not guarantee that
+// all of the values come from the same version of a Kubernetes volume.
+func DirFS(fsys fs.FS, path string, opts ...DirOption) Source {
+	return DirsFS(fsys, []string{path}, opts...)
+}
+
+// DirsFS returns a Source like Dirs which reads the directories at paths in fsys.
+func DirsFS(fsys fs.FS, paths []string, opts ...DirOption) Source {
+	return dirSource{fsys: fsys, paths: paths, opts: newDirOptions(opts)}
+}
+
+type dirSource struct {
+	fsys  fs.FS
+	paths []string
+	opts  dirOptions
+}
+
+func (ds dirSource) Name() string {
+	return strings.Join(ds.paths, dirSourceNameSep)
+}
+
+func (ds dirSource) Load() (map[string]string, error) {
+	res := map[string]string{}
+
+	for i := len(ds.paths) - 1; i >= 0; i-- {
+		vals, err := readDir(ds.fsys, ds.paths[i], ds.opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range vals {
+			res[k] = v
+		}
+	}
+
+	return res, nil
+}
+
+func readDir(fsys fs.FS, dir string, opts dirOptions) (map[string]string, error) {
+	dir, err := kubernetesDataPath(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := readDirEntries(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]string{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), kubernetesHiddenPrefix) {
+			continue
+		}
+
+		key := opts.keyFunc(entry.Name())
+		if key == "" {
+			continue
+		}
+
+		filePath := joinPath(fsys, dir, entry.Name())
+		info, err := statFile(fsys, filePath)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		val, err := readFileLimited(fsys, filePath, opts.maxFileSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := res[key]; ok {
+			return nil, fmt.Errorf("%w: %s", errDuplicateFlatKey, key)
+		}
+
+		if !opts.keepNewlines {
+			val = strings.TrimRight(val, "\r\n")
+		}
+
+		res[key] = val
+	}
+
+	return res, nil
+}
+
+// kubernetesDataPath returns the directory to read the files of dir from, which is the target of
+// its ..data symlink if it has one.
+func kubernetesDataPath(fsys fs.FS, dir string) (string, error) {
+	dataDir := joinPath(fsys, dir, kubernetesDataDir)
+
+	if fsys != nil {
+		if _, err := fs.Stat(fsys, dataDir); err == nil {
+			return dataDir, nil
+		}
+
+		return dir, nil
+	}
+
+	if _, err := os.Lstat(dataDir); err == nil {
+		return filepath.EvalSymlinks(dataDir)
+	}
+
+	return dir, nil
+}
+
+// readFileLimited reads a file, returning an error if it is larger than limit bytes.
+func readFileLimited(fsys fs.FS, path string, limit int64) (string, error) {
+	f, err := openFile(fsys, path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(b)) > limit {
+		return "", fmt.Errorf("%w of %d bytes: %s", errFileTooLarge, limit, path)
+	}
+
+	return string(b), nil
+}
+
+// fileKey is the default function for converting the name of a file read by Dir to its key.
+func fileKey(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
@@ -0,0 +1,229 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// writeFiles writes files with the given names and contents to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0600)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// kubernetesVolume creates a directory laid out like a ConfigMap volume mounted by Kubernetes, and returns it.
+func kubernetesVolume(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	writeFiles(t, filepath.Join(dir, "..2024_01_01_00_00_00.000000001"), files)
+
+	err := os.Symlink("..2024_01_01_00_00_00.000000001", filepath.Join(dir, "..data"))
+	if err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	for name := range files {
+		err = os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func Test_Dir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"DB_HOST":      "localhost\n",
+		"db-port":      "5432\r\n",
+		"log.level":    "info",
+		"cert":         "line 1\nline 2\n\n",
+		"sub/IGNORED":  "x",
+		"..hidden":     "x",
+		"EMPTY":        "",
+		".dotted-name": "y",
+	})
+
+	res, err := Dir(dir).Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{
+			"DB_HOST":      "localhost",
+			"DB_PORT":      "5432",
+			"LOG_LEVEL":    "info",
+			"CERT":         "line 1\nline 2",
+			"EMPTY":        "",
+			"_DOTTED_NAME": "y",
+		}, res)
+	}
+}
+
+func Test_Dir_KeepNewlines(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"CERT": "line 1\n"})
+
+	res, err := Dir(dir, WithKeepNewlines()).Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"CERT": "line 1\n"}, res)
+	}
+}
+
+func Test_Dir_FileKeyFunc(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-host": "localhost", "README": "skipped"})
+
+	keyFunc := func(name string) string {
+		if name == "README" {
+			return ""
+		}
+
+		return "APP_" + strings.ToUpper(name)
+	}
+
+	res, err := Dir(dir, WithFileKeyFunc(keyFunc)).Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"APP_DB-HOST": "localhost"}, res)
+	}
+}
+
+func Test_Dir_MaxFileSize(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"SMALL": "1234", "LARGE": "12345"})
+
+	_, err := Dir(dir, WithMaxFileSize(4)).Load()
+
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, errFileTooLarge))
+		assert.Equal(t, "file is larger than the size limit of 4 bytes: "+filepath.Join(dir, "LARGE"), err.Error())
+	}
+}
+
+func Test_Dir_DuplicateKey(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-host": "a", "db.host": "b"})
+
+	_, err := Dir(dir).Load()
+
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, errDuplicateFlatKey))
+		assert.Equal(t, "more than one value has the key: DB_HOST", err.Error())
+	}
+}
+
+func Test_Dir_MissingDir(t *testing.T) {
+	_, err := Dir(filepath.Join(t.TempDir(), "missing")).Load()
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func Test_Dir_KubernetesVolume(t *testing.T) {
+	dir := kubernetesVolume(t, map[string]string{"DB_HOST": "localhost\n", "DB_PORT": "5432\n"})
+
+	res, err := Dir(dir).Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432"}, res)
+	}
+}
+
+func Test_Dir_KubernetesVolumeUpdated(t *testing.T) {
+	dir := kubernetesVolume(t, map[string]string{"DB_HOST": "old"})
+
+	// update the volume in the same way as Kubernetes: write a new directory, then switch ..data to it
+	writeFiles(t, filepath.Join(dir, "..2024_01_02_00_00_00.000000002"), map[string]string{"DB_HOST": "new", "DB_PORT": "5432"})
+	err := os.Symlink("..2024_01_02_00_00_00.000000002", filepath.Join(dir, "..data_tmp"))
+	if err == nil {
+		err = os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))
+	}
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	res, err := Dir(dir).Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"DB_HOST": "new", "DB_PORT": "5432"}, res)
+	}
+}
+
+func Test_Dirs_Priority(t *testing.T) {
+	secrets, config := t.TempDir(), t.TempDir()
+	writeFiles(t, secrets, map[string]string{"DB_PASSWORD": "secret", "DB_HOST": "secret-host"})
+	writeFiles(t, config, map[string]string{"DB_HOST": "config-host", "LOG_LEVEL": "info"})
+	src := Dirs([]string{secrets, config})
+
+	res, err := src.Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, secrets+","+config, src.Name())
+		assert.Equal(t, map[string]string{"DB_PASSWORD": "secret", "DB_HOST": "secret-host", "LOG_LEVEL": "info"}, res)
+	}
+}
+
+func Test_Parse_Dir(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"db-host": "dir-host\n", "db-port": "5432\n"})
+	s := struct {
+		Host string `phnenv:"DB_HOST"`
+		Port int    `phnenv:"DB_PORT"`
+	}{}
+
+	err := Parse(&s, WithSources(Map("env", map[string]string{"DB_HOST": "env-host"}), Dir(dir)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "env-host", s.Host)
+		assert.Equal(t, 5432, s.Port)
+	}
+}
+
+func Test_DirFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/db-host":  {Data: []byte("localhost\n")},
+		"config/..hidden": {Data: []byte("x")},
+		"config/sub/x":    {Data: []byte("x")},
+		"secrets/DB_HOST": {Data: []byte("secret-host")},
+		"secrets/TOKEN":   {Data: []byte("abc")},
+	}
+
+	res, err := DirsFS(fsys, []string{"secrets", "config"}).Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"DB_HOST": "secret-host", "TOKEN": "abc"}, res)
+	}
+}
+
+func Test_DirFS_KubernetesVolume(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/..data/DB_HOST":                {Data: []byte("new")},
+		"config/..2024_01_01_00_00_00/DB_HOST": {Data: []byte("old")},
+	}
+
+	res, err := DirFS(fsys, "config").Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"DB_HOST": "new"}, res)
+	}
+}
+
+func Test_DirFS_MaxFileSize(t *testing.T) {
+	fsys := fstest.MapFS{"config/LARGE": {Data: []byte("12345")}}
+
+	_, err := DirFS(fsys, "config", WithMaxFileSize(4)).Load()
+
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "file is larger than the size limit of 4 bytes: config/LARGE", err.Error())
+	}
+}
@@ -0,0 +1,165 @@
+package phnenv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+const (
+	dotEnvExportPrefix = "export "
+
+	errLineWrapFmt = "line %d: %w"
+)
+
+var (
+	errDotEnvNoEquals     = errors.New("expected KEY=VALUE")
+	errDotEnvEmptyKey     = errors.New("key is empty")
+	errDotEnvInvalidKey   = errors.New("key contains whitespace")
+	errDotEnvUnterminated = errors.New("quoted value is not terminated")
+	errDotEnvTrailing     = errors.New("unexpected characters after quoted value")
+)
+
+// DotEnv returns a Source containing the variables in the .env file at path.
+// The file is read every time the source is loaded.
+//
+// Each line of the file is either blank, a comment starting with "#", or a KEY=VALUE assignment,
+// optionally preceded by "export ". Values may be:
+//
+//	unquoted          surrounding whitespace is trimmed and a " #" starts a comment
+//	'single quoted'   taken literally
+//	"double quoted"   the escapes \n, \r, \t, \" and \\ are replaced
+func DotEnv(path string) Source {
+	return dotEnvSource{path: path}
+}
+
+// DotEnvFS returns a Source like DotEnv which reads the .env file at path in fsys, such as an embed.FS.
+func DotEnvFS(fsys fs.FS, path string) Source {
+	return dotEnvSource{fsys: fsys, path: path}
+}
+
+type dotEnvSource struct {
+	fsys fs.FS
+	path string
+}
+
+func (ds dotEnvSource) Name() string {
+	return ds.path
+}
+
+func (ds dotEnvSource) Load() (map[string]string, error) {
+	b, err := readFile(ds.fsys, ds.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDotEnv(b)
+}
+
+func parseDotEnv(b []byte) (map[string]string, error) {
+	res := map[string]string{}
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, val, err := parseDotEnvLine(line)
+		if err != nil {
+			return nil, fmt.Errorf(errLineWrapFmt, lineNum, err)
+		}
+
+		res[key] = val
+	}
+
+	return res, sc.Err()
+}
+
+func parseDotEnvLine(line string) (string, string, error) {
+	line = strings.TrimPrefix(line, dotEnvExportPrefix)
+
+	i := strings.Index(line, "=")
+	if i < 0 {
+		return "", "", errDotEnvNoEquals
+	}
+
+	key := strings.TrimSpace(line[:i])
+	if key == "" {
+		return "", "", errDotEnvEmptyKey
+	}
+	if strings.ContainsAny(key, " \t") {
+		return "", "", errDotEnvInvalidKey
+	}
+
+	val, err := parseDotEnvValue(strings.TrimSpace(line[i+1:]))
+
+	return key, val, err
+}
+
+func parseDotEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", errDotEnvUnterminated
+		}
+
+		return raw[1 : end+1], checkDotEnvTrailing(raw[end+2:])
+	case '"':
+		var sb strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '"':
+				return sb.String(), checkDotEnvTrailing(raw[i+1:])
+			case c == '\\' && i+1 < len(raw):
+				i++
+				sb.WriteString(unescapeDotEnv(raw[i]))
+			default:
+				sb.WriteByte(c)
+			}
+		}
+
+		return "", errDotEnvUnterminated
+	default:
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		}
+
+		return strings.TrimSpace(raw), nil
+	}
+}
+
+func unescapeDotEnv(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\':
+		return string(c)
+	default:
+		return "\\" + string(c)
+	}
+}
+
+// checkDotEnvTrailing checks that only whitespace or a comment follows a quoted value.
+func checkDotEnvTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return errDotEnvTrailing
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var test_parseDotEnv = []struct {
+	name     string
+	input    string
+	expected map[string]string
+}{
+	{"empty file", "", map[string]string{}},
+	{"comments and blank lines", "# comment\n\n  # indented comment\nA=1\n", map[string]string{"A": "1"}},
+	{"unquoted value is trimmed", "A =  some value  \n", map[string]string{"A": "some value"}},
+	{"unquoted value with comment", "A=value # comment", map[string]string{"A": "value"}},
+	{"unquoted value with hash", "A=va#lue", map[string]string{"A": "va#lue"}},
+	{"empty value", "A=", map[string]string{"A": ""}},
+	{"value containing equals", "A=b=c", map[string]string{"A": "b=c"}},
+	{"export prefix", "export A=1", map[string]string{"A": "1"}},
+	{"single quoted", `A='  $x \n # y '`, map[string]string{"A": `  $x \n # y `}},
+	{"double quoted with escapes", `A="a\nb\t\"c\"\\ \x" # comment`, map[string]string{"A": "a\nb\t\"c\"\\ \\x"}},
+	{"windows line endings", "A=1\r\nB=2\r\n", map[string]string{"A": "1", "B": "2"}},
+	{"later lines win", "A=1\nA=2", map[string]string{"A": "2"}},
+}
+
+func Test_parseDotEnv(t *testing.T) {
+	for _, tt := range test_parseDotEnv {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseDotEnv([]byte(tt.input))
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+var test_parseDotEnv_Errors = []struct {
+	name     string
+	input    string
+	expected string
+}{
+	{"no equals", "A=1\nB", "line 2: expected KEY=VALUE"},
+	{"empty key", "=1", "line 1: key is empty"},
+	{"key with space", "A B=1", "line 1: key contains whitespace"},
+	{"unterminated single quote", "A='abc", "line 1: quoted value is not terminated"},
+	{"unterminated double quote", `A="abc\"`, "line 1: quoted value is not terminated"},
+	{"text after quotes", `A="abc" def`, "line 1: unexpected characters after quoted value"},
+}
+
+func Test_parseDotEnv_Errors(t *testing.T) {
+	for _, tt := range test_parseDotEnv_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDotEnv([]byte(tt.input))
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func Test_Parse_DotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	err := os.WriteFile(path, []byte("PORT=8080\nNAME=\"my app\"\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := struct {
+		Port int    `phnenv:"PORT"`
+		Name string `phnenv:"NAME"`
+	}{}
+
+	err = Parse(&s, WithSources(DotEnv(path)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, 8080, s.Port)
+		assert.Equal(t, "my app", s.Name)
+	}
+}
+
+func Test_Parse_DotEnv_ErrorIncludesPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	err := os.WriteFile(path, []byte("PORT\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := struct {
+		Port int `phnenv:"PORT"`
+	}{}
+
+	err = Parse(&s, WithSources(DotEnv(path)))
+
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `phnenv: source "`+path+`": line 1: expected KEY=VALUE`, err.Error())
+	}
+}
+
+func Test_Parse_DotEnv_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	s := struct {
+		Port int `phnenv:"PORT"`
+	}{}
+
+	err := Parse(&s, WithSources(DotEnv(path)))
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,177 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	expandDefaultOp  = ":-"
+	expandRequiredOp = ":?"
+
+	defaultRequiredMsg = "parameter null or not set"
+)
+
+var (
+	errExpansionSyntax   = errors.New("invalid variable reference")
+	errExpansionCycle    = errors.New("variable reference cycle")
+	errExpansionRequired = errors.New("required variable is not set")
+)
+
+// expander resolves variable references in config values against a config source.
+type expander struct {
+	conf confGetter
+
+	// stack holds the keys whose values are currently being expanded, for detecting reference cycles.
+	stack []string
+}
+
+// expand resolves all variable references in conf, which is the value of key.
+func expand(key string, conf string, c confGetter) (string, error) {
+	e := expander{conf: c, stack: []string{key}}
+
+	return e.expand(conf)
+}
+
+func (e *expander) expand(s string) (string, error) {
+	if strings.IndexByte(s, '$') < 0 {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		next := s[i+1]
+		switch {
+		case next == '$':
+			sb.WriteByte('$')
+			i++
+		case next == '{':
+			end := closingBrace(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated %q", errExpansionSyntax, s[i:])
+			}
+
+			val, err := e.expandBraced(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString(val)
+			i = end
+		case isNameStart(next):
+			end := i + 1
+			for end < len(s) && isNameChar(s[end]) {
+				end++
+			}
+
+			val, _, err := e.lookup(s[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString(val)
+			i = end - 1
+		default:
+			sb.WriteByte('$')
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// expandBraced resolves the expression between "${" and "}".
+func (e *expander) expandBraced(expr string) (string, error) {
+	nameEnd := 0
+	for nameEnd < len(expr) && isNameChar(expr[nameEnd]) {
+		nameEnd++
+	}
+
+	name := expr[:nameEnd]
+	if len(name) < 1 || !isNameStart(name[0]) {
+		return "", fmt.Errorf("%w: ${%s}", errExpansionSyntax, expr)
+	}
+
+	val, ok, err := e.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	op := expr[nameEnd:]
+	switch {
+	case op == "":
+		return val, nil
+	case hasPrefix(op, expandDefaultOp):
+		if ok && len(val) > 0 {
+			return val, nil
+		}
+
+		return e.expand(op[len(expandDefaultOp):])
+	case hasPrefix(op, expandRequiredOp):
+		if ok && len(val) > 0 {
+			return val, nil
+		}
+
+		msg := op[len(expandRequiredOp):]
+		if len(msg) < 1 {
+			msg = defaultRequiredMsg
+		}
+
+		return "", fmt.Errorf("%w: %s: %s", errExpansionRequired, name, msg)
+	default:
+		return "", fmt.Errorf("%w: ${%s}", errExpansionSyntax, expr)
+	}
+}
+
+// lookup gets the expanded value of a referenced variable.
+// The bool result is false if the variable does not exist in the config source.
+func (e *expander) lookup(name string) (string, bool, error) {
+	for i, key := range e.stack {
+		if key == name {
+			cycle := append(append([]string{}, e.stack[i:]...), name)
+			return "", false, fmt.Errorf("%w: %s", errExpansionCycle, strings.Join(cycle, " -> "))
+		}
+	}
+
+	conf, ok := e.conf(name)
+	if !ok {
+		return "", false, nil
+	}
+
+	e.stack = append(e.stack, name)
+	res, err := e.expand(conf)
+	e.stack = e.stack[:len(e.stack)-1]
+
+	return res, true, err
+}
+
+// closingBrace returns the index of the "}" matching the "{" at index open, or -1 if there is none.
+func closingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,140 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func mapGetter(m map[string]string) confGetter {
+	return func(s string) (string, bool) {
+		v, ok := m[s]
+		return v, ok
+	}
+}
+
+var test_expand_PositiveCases = []struct {
+	Name     string
+	Input    string
+	Conf     map[string]string
+	Expected string
+}{
+	{"no references", "plain value", nil, "plain value"},
+	{"braced", "postgres://${DB_USER}@${DB_HOST}/app", map[string]string{"DB_USER": "app", "DB_HOST": "db"}, "postgres://app@db/app"},
+	{"unbraced", "$DB_USER@$DB_HOST/app", map[string]string{"DB_USER": "app", "DB_HOST": "db"}, "app@db/app"},
+	{"unset is empty", "a${MISSING}b$MISSING", nil, "ab"},
+	{"default when unset", "${PORT:-8080}", nil, "8080"},
+	{"default when empty", "${PORT:-8080}", map[string]string{"PORT": ""}, "8080"},
+	{"default not used when set", "${PORT:-8080}", map[string]string{"PORT": "9090"}, "9090"},
+	{"nested default", "${A:-${B:-c}}", nil, "c"},
+	{"required set", "${A:?A is required}", map[string]string{"A": "x"}, "x"},
+	{"recursive", "${A}", map[string]string{"A": "<$B>", "B": "b"}, "<b>"},
+	{"escaped dollar", "cost: $$5", nil, "cost: $5"},
+	{"lone dollar", "a $ b $", nil, "a $ b $"},
+	{"dollar before digit", "$1", nil, "$1"},
+	{"same reference twice", "$A$A", map[string]string{"A": "x"}, "xx"},
+}
+
+func Test_expand_PositiveCases(t *testing.T) {
+	for _, c := range test_expand_PositiveCases {
+		t.Run(c.Name, func(t *testing.T) {
+			res, err := expand("KEY", c.Input, mapGetter(c.Conf))
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, c.Expected, res)
+			}
+		})
+	}
+}
+
+var test_expand_NegativeCases = []struct {
+	Name        string
+	Input       string
+	Conf        map[string]string
+	ExpectedErr error
+	ErrPart     string
+}{
+	{"unterminated", "${A", nil, errExpansionSyntax, "unterminated"},
+	{"empty name", "${}", nil, errExpansionSyntax, "${}"},
+	{"unknown operator", "${A:+x}", nil, errExpansionSyntax, "${A:+x}"},
+	{"required unset", "${A:?A must be set}", nil, errExpansionRequired, "A: A must be set"},
+	{"required empty without message", "${A:?}", map[string]string{"A": ""}, errExpansionRequired, defaultRequiredMsg},
+	{"self reference", "${KEY}", nil, errExpansionCycle, "KEY -> KEY"},
+	{"indirect cycle", "$A", map[string]string{"A": "$B", "B": "${A}"}, errExpansionCycle, "A -> B -> A"},
+}
+
+func Test_expand_NegativeCases(t *testing.T) {
+	for _, c := range test_expand_NegativeCases {
+		t.Run(c.Name, func(t *testing.T) {
+			_, err := expand("KEY", c.Input, mapGetter(c.Conf))
+
+			if assert.True(t, errors.Is(err, c.ExpectedErr)) {
+				assert.Contains(t, err.Error(), c.ErrPart)
+			}
+		})
+	}
+}
+
+func Test_parse_WithExpansion_ExpandsFieldValues(t *testing.T) {
+	s := struct {
+		URL      string   `phnenv:"DATABASE_URL"`
+		Literal  string   `phnenv:"PASSWORD,noexpand"`
+		Port     int      `phnenv:"PORT"`
+		Replicas []string `phnenv:"REPLICAS"`
+	}{}
+	g := mapGetter(map[string]string{
+		"DATABASE_URL": "postgres://${DB_USER}@${DB_HOST:-localhost}/app",
+		"DB_USER":      "app",
+		"PASSWORD":     "pa$$w${rd",
+		"PORT":         "${BASE_PORT:-80}",
+		"REPLICAS":     "$DB_HOST,replica",
+	})
+
+	err := parse(g, &s, WithExpansion())
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "postgres://app@localhost/app", s.URL)
+		assert.Equal(t, "pa$$w${rd", s.Literal)
+		assert.Equal(t, 80, s.Port)
+		assert.Equal(t, []string{"", "replica"}, s.Replicas)
+	}
+}
+
+func Test_parse_WithoutExpansion_KeepsReferences(t *testing.T) {
+	s := struct {
+		F string `phnenv:"TESTENV"`
+	}{}
+
+	err := parse(testGetter("${OTHER}"), &s)
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "${OTHER}", s.F)
+	}
+}
+
+func Test_parse_WithExpansion_ErrorNamesField(t *testing.T) {
+	s := struct {
+		F string `phnenv:"TESTENV"`
+	}{}
+
+	err := parse(testGetter("${TESTENV}"), &s, WithExpansion())
+
+	if assert.True(t, errors.Is(err, errExpansionCycle)) {
+		assert.Contains(t, err.Error(), `field "F"`)
+	}
+}
+
+func Test_Parse_WithExpansion_ReferencesReadFromEarliestSource(t *testing.T) {
+	s := struct {
+		URL string `phnenv:"URL"`
+	}{}
+
+	err := Parse(&s, WithExpansion(), WithSources(
+		Map("overrides", map[string]string{"HOST": "override-host"}),
+		Map("defaults", map[string]string{"URL": "x://${HOST}", "HOST": "default-host"}),
+	))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "x://override-host", s.URL)
+	}
+}
@@ -0,0 +1,53 @@
+package phnenv
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// The functions in this file are used by file based sources to read from either an fs.FS, or the OS file system
+// if fsys is nil. Names are fs.FS paths, which are slash separated and relative, for an fs.FS,
+// and OS paths otherwise.
+
+func readFile(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(name)
+	}
+
+	return fs.ReadFile(fsys, name)
+}
+
+func openFile(fsys fs.FS, name string) (fs.File, error) {
+	if fsys == nil {
+		return os.Open(name)
+	}
+
+	return fsys.Open(name)
+}
+
+// statFile returns information about a file, following symlinks.
+func statFile(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(name)
+	}
+
+	return fs.Stat(fsys, name)
+}
+
+func readDirEntries(fsys fs.FS, name string) ([]fs.DirEntry, error) {
+	if fsys == nil {
+		return os.ReadDir(name)
+	}
+
+	return fs.ReadDir(fsys, name)
+}
+
+func joinPath(fsys fs.FS, elem ...string) string {
+	if fsys == nil {
+		return filepath.Join(elem...)
+	}
+
+	return path.Join(elem...)
+}
@@ -0,0 +1,72 @@
+package phnenv
+
+import (
+	"embed"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed testdata/defaults
+var embeddedDefaults embed.FS
+
+var test_FileSourcesFS = []struct {
+	name     string
+	src      func(fsys fs.FS, path string) Source
+	content  string
+	expected map[string]string
+}{
+	{"DotEnvFS", DotEnvFS, "DB_HOST=localhost\n", map[string]string{"DB_HOST": "localhost"}},
+	{"JSONFileFS", func(fsys fs.FS, path string) Source { return JSONFileFS(fsys, path) },
+		`{"db": {"host": "localhost"}}`, map[string]string{"DB_HOST": "localhost"}},
+	{"YAMLFileFS", func(fsys fs.FS, path string) Source { return YAMLFileFS(fsys, path) },
+		"db:\n  host: localhost\n", map[string]string{"DB_HOST": "localhost"}},
+	{"TOMLFileFS", func(fsys fs.FS, path string) Source { return TOMLFileFS(fsys, path) },
+		"[db]\nhost = \"localhost\"\n", map[string]string{"DB_HOST": "localhost"}},
+	{"PropertiesFileFS", func(fsys fs.FS, path string) Source { return PropertiesFileFS(fsys, path) },
+		"db.host=localhost\n", map[string]string{"DB_HOST": "localhost"}},
+	{"INIFileFS", func(fsys fs.FS, path string) Source { return INIFileFS(fsys, path) },
+		"[db]\nhost = localhost\n", map[string]string{"DB_HOST": "localhost"}},
+}
+
+func Test_FileSourcesFS(t *testing.T) {
+	for _, tt := range test_FileSourcesFS {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"config/file": {Data: []byte(tt.content)}}
+			src := tt.src(fsys, "config/file")
+
+			res, err := src.Load()
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, "config/file", src.Name())
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func Test_FileSourcesFS_MissingFile(t *testing.T) {
+	for _, tt := range test_FileSourcesFS {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.src(fstest.MapFS{}, "config/file").Load()
+
+			assert.True(t, errors.Is(err, fs.ErrNotExist))
+		})
+	}
+}
+
+func Test_Parse_EmbeddedDefaults(t *testing.T) {
+	s := struct {
+		LogLevel string `phnenv:"LOG_LEVEL"`
+		Port     int    `phnenv:"DB_PORT"`
+	}{}
+
+	err := Parse(&s, WithSources(Map("env", map[string]string{"LOG_LEVEL": "debug"}), DotEnvFS(embeddedDefaults, "testdata/defaults/defaults.env")))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "debug", s.LogLevel)
+		assert.Equal(t, 5432, s.Port)
+	}
+}
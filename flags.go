@@ -0,0 +1,164 @@
+package phnenv
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const flagsSourceName = "flags"
+
+var errFlagRedefined = errors.New("flag is already defined")
+
+// BindFlags defines a flag on fs for every field of the struct pointed to by v which has a phnenv tag,
+// including the fields of nested structs. It returns a Source holding the values of the flags which are
+// set when fs is parsed. To let flags override environment variables, list the Source before Env():
+//
+//	flags, err := phnenv.BindFlags(flag.CommandLine, &conf)
+//	...
+//	flag.Parse()
+//	err = phnenv.Parse(&conf, phnenv.WithSources(flags, phnenv.Env()))
+//
+// A flag's name is the value of the field's flag: tag option if there is one. Otherwise it is the field's key
+// in lower case, with underscores replaced by dashes, so the key DB_HOST becomes the flag -db-host.
+// The flag's usage text is the value of the field's desc: tag option.
+//
+// Flag values are checked when fs is parsed, in the same way as Parse would check them, so fs reports
+// invalid values along with its usage text. Bool fields can be set without a value, as in -verbose.
+// A flag for a slice field can be given more than once, and each value is appended to the slice.
+//
+// v is not modified. BindFlags returns an error if v is not a pointer to a struct, if the struct has an
+// invalid tag, or if a flag with the same name is already defined on fs.
+func BindFlags(fs *flag.FlagSet, v interface{}) (Source, error) {
+	src, err := bindFlags(fs, v)
+	if err != nil {
+		return nil, fmt.Errorf(errWrapFmt, err)
+	}
+
+	return src, nil
+}
+
+func bindFlags(fs *flag.FlagSet, v interface{}) (*flagsSource, error) {
+	sv, err := validateInput(v)
+	if err != nil {
+		return nil, err
+	}
+
+	sp, err := planFor(sv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	src := &flagsSource{values: map[string]string{}}
+
+	err = bindPlanFlags(fs, src, sp, sv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	return src, nil
+}
+
+func bindPlanFlags(fs *flag.FlagSet, src *flagsSource, sp *structPlan, t reflect.Type) error {
+	for _, fp := range sp.fields {
+		ft := t.Field(fp.index).Type
+
+		if fp.nested != nil {
+			err := bindPlanFlags(fs, src, fp.nested, derefType(ft))
+			if err != nil {
+				return fmt.Errorf(fieldWrapFmt, fp.name, err)
+			}
+
+			continue
+		}
+
+		name := flagName(fp)
+		if fs.Lookup(name) != nil {
+			return fmt.Errorf(fieldWrapFmt, fp.name, fmt.Errorf("%w: -%s", errFlagRedefined, name))
+		}
+
+		fs.Var(&flagValue{src: src, fp: fp, t: ft}, name, fp.opts.Desc)
+	}
+
+	return nil
+}
+
+// flagName returns the name of the flag for a field.
+func flagName(fp *fieldPlan) string {
+	if fp.opts.FlagName != "" {
+		return fp.opts.FlagName
+	}
+
+	return strings.ReplaceAll(strings.ToLower(fp.key), "_", "-")
+}
+
+// flagsSource is the Source returned by BindFlags.
+type flagsSource struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (fls *flagsSource) Name() string {
+	return flagsSourceName
+}
+
+func (fls *flagsSource) Load() (map[string]string, error) {
+	fls.mu.Lock()
+	defer fls.mu.Unlock()
+
+	res := make(map[string]string, len(fls.values))
+	for k, v := range fls.values {
+		res[k] = v
+	}
+
+	return res, nil
+}
+
+// flagValue is the flag.Value for a field. It stores the flag's value in the source under the field's key.
+type flagValue struct {
+	src *flagsSource
+	fp  *fieldPlan
+	t   reflect.Type
+}
+
+func (flv *flagValue) String() string {
+	if flv.src == nil {
+		return "" // the flag package calls String on a zero flagValue to find out if the default value is zero
+	}
+
+	flv.src.mu.Lock()
+	defer flv.src.mu.Unlock()
+
+	return flv.src.values[flv.fp.key]
+}
+
+func (flv *flagValue) Set(s string) error {
+	flv.src.mu.Lock()
+	defer flv.src.mu.Unlock()
+
+	prev, ok := flv.src.values[flv.fp.key]
+	if ok && flv.isSlice() {
+		s = prev + flv.fp.opts.SliceSep + s
+	}
+
+	err := flv.fp.set(s, reflect.New(flv.t).Elem())
+	if err != nil {
+		return err
+	}
+
+	flv.src.values[flv.fp.key] = s
+
+	return nil
+}
+
+// IsBoolFlag lets bool fields be set with just -name, as flag.Bool does.
+func (flv *flagValue) IsBoolFlag() bool {
+	return derefType(flv.t).Kind() == reflect.Bool
+}
+
+func (flv *flagValue) isSlice() bool {
+	return derefType(flv.t).Kind() == reflect.Slice && !hasTypeSetter(derefType(flv.t))
+}
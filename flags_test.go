@@ -0,0 +1,160 @@
+package phnenv
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+type flagsTestDB struct {
+	Host string `phnenv:"DB_HOST,desc:database host name"`
+	Port int    `phnenv:"DB_PORT,flag:port"`
+}
+
+type flagsTestConf struct {
+	Name    string   `phnenv:"APP_NAME"`
+	Verbose bool     `phnenv:"VERBOSE"`
+	Tags    []string `phnenv:"TAGS,sep:;"`
+	IPs     []net.IP `phnenv:"IPS"`
+	Count   *uint8   `phnenv:"COUNT"`
+	DB      *flagsTestDB
+	Ignored string
+}
+
+func newTestFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+
+	return fs
+}
+
+func Test_BindFlags_FlagsOverrideEnv(t *testing.T) {
+	fs := newTestFlagSet()
+	s := flagsTestConf{}
+	src, err := BindFlags(fs, &s)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = fs.Parse([]string{"-app-name", "from-flag", "-verbose", "-tags", "a", "-tags", "b;c", "-port=99", "-count", "7"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = Parse(&s, WithSources(src, Map("env", map[string]string{
+		"APP_NAME": "from-env",
+		"DB_HOST":  "db",
+		"DB_PORT":  "5432",
+		"IPS":      "127.0.0.1",
+	})))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "from-flag", s.Name)
+		assert.True(t, s.Verbose)
+		assert.Equal(t, []string{"a", "b", "c"}, s.Tags)
+		assert.Equal(t, []net.IP{net.ParseIP("127.0.0.1")}, s.IPs)
+		assert.Equal(t, uint8(7), *s.Count)
+		assert.Equal(t, &flagsTestDB{Host: "db", Port: 99}, s.DB)
+	}
+}
+
+func Test_BindFlags_FlagOverridesAliasInEnv(t *testing.T) {
+	fs := newTestFlagSet()
+	s := struct {
+		Port int `phnenv:"PORT|OLD_PORT"`
+	}{}
+	src, err := BindFlags(fs, &s)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = fs.Parse([]string{"-port", "99"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = Parse(&s, WithSources(src, Map("env", map[string]string{"OLD_PORT": "5432"})))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, 99, s.Port)
+	}
+}
+
+func Test_BindFlags_RegistersFlags(t *testing.T) {
+	fs := newTestFlagSet()
+
+	_, err := BindFlags(fs, &flagsTestConf{})
+
+	if assert.Nil(t, err) {
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+		assert.Equal(t, []string{"app-name", "count", "db-host", "ips", "port", "tags", "verbose"}, names)
+		assert.Equal(t, "database host name", fs.Lookup("db-host").Usage)
+	}
+}
+
+func Test_BindFlags_InvalidValue_ReportedByFlagSet(t *testing.T) {
+	fs := newTestFlagSet()
+	_, err := BindFlags(fs, &flagsTestConf{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = fs.Parse([]string{"-count", "300"})
+
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `invalid value "300" for flag -count: environment value overflows numeric type`, err.Error())
+	}
+}
+
+func Test_BindFlags_FlagNotSet_SourceEmpty(t *testing.T) {
+	fs := newTestFlagSet()
+	src, err := BindFlags(fs, &flagsTestConf{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = fs.Parse(nil)
+	vals, loadErr := src.Load()
+
+	assert.Nil(t, err)
+	assert.Nil(t, loadErr)
+	assert.Equal(t, map[string]string{}, vals)
+	assert.Equal(t, "flags", src.Name())
+}
+
+func Test_BindFlags_PrintDefaults(t *testing.T) {
+	fs := newTestFlagSet()
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	_, err := BindFlags(fs, &struct {
+		Verbose bool   `phnenv:"VERBOSE,desc:log more"`
+		Host    string `phnenv:"HOST,desc:the host"`
+	}{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	fs.PrintDefaults()
+
+	assert.Equal(t, "  -host value\n    \tthe host\n  -verbose\n    \tlog more\n", out.String())
+}
+
+func Test_BindFlags_FlagRedefined_ReturnsError(t *testing.T) {
+	fs := newTestFlagSet()
+	fs.String("port", "", "")
+
+	_, err := BindFlags(fs, &flagsTestConf{})
+
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, errFlagRedefined))
+		assert.Equal(t, `phnenv: field "DB": field "Port": flag is already defined: -port`, err.Error())
+	}
+}
+
+func Test_BindFlags_NotStructPointer_ReturnsError(t *testing.T) {
+	_, err := BindFlags(newTestFlagSet(), flagsTestConf{})
+
+	assert.True(t, errors.Is(err, errMustBeStructPtr))
+}
@@ -0,0 +1,185 @@
+package phnenv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultKeySeparator = "_"
+	listValueSeparator  = ","
+)
+
+var errDuplicateFlatKey = errors.New("more than one value has the key")
+
+// KeyCase is the case of the keys made by flattening a nested document, such as a JSON file.
+type KeyCase int
+
+const (
+	// UpperCase converts keys to upper case, which matches the usual names of environment variables.
+	UpperCase KeyCase = iota
+
+	// LowerCase converts keys to lower case.
+	LowerCase
+
+	// PreserveCase leaves keys in the case used in the document.
+	PreserveCase
+)
+
+// FlattenOption configures how the keys of a nested document are flattened into config keys.
+type FlattenOption func(*flattenOptions)
+
+type flattenOptions struct {
+	sep     string
+	keyCase KeyCase
+}
+
+func newFlattenOptions(opts []FlattenOption) flattenOptions {
+	o := flattenOptions{sep: defaultKeySeparator, keyCase: UpperCase}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithKeySeparator sets the string which joins the keys of nested values. The default is "_",
+// so the value of "host" in {"db": {"host": "localhost"}} has the key DB_HOST.
+func WithKeySeparator(sep string) FlattenOption {
+	return func(o *flattenOptions) {
+		o.sep = sep
+	}
+}
+
+// WithKeyCase sets the case of flattened keys. The default is UpperCase.
+func WithKeyCase(c KeyCase) FlattenOption {
+	return func(o *flattenOptions) {
+		o.keyCase = c
+	}
+}
+
+// flattener builds a flat map of config values from the nested values of a document.
+// Documents are represented by map[string]interface{} for objects, []interface{} for arrays,
+// string, json.Number or bool for scalar values, and nil for null values.
+type flattener struct {
+	opts flattenOptions
+	res  map[string]string
+}
+
+func newFlattener(opts []FlattenOption) *flattener {
+	return &flattener{opts: newFlattenOptions(opts), res: map[string]string{}}
+}
+
+// key joins the key of a value's parent with the value's own name, and converts it to the configured case.
+func (f *flattener) key(parent string, name string) string {
+	switch f.opts.keyCase {
+	case UpperCase:
+		name = strings.ToUpper(name)
+	case LowerCase:
+		name = strings.ToLower(name)
+	}
+
+	if parent == "" {
+		return name
+	}
+
+	return parent + f.opts.sep + name
+}
+
+// dottedKey joins each "." separated part of name to parent, in the same way as key.
+func (f *flattener) dottedKey(parent string, name string) string {
+	for _, part := range strings.Split(name, ".") {
+		parent = f.key(parent, part)
+	}
+
+	return parent
+}
+
+func (f *flattener) indexKey(parent string, i int) string {
+	return f.key(parent, strconv.Itoa(i))
+}
+
+func (f *flattener) set(key string, val string) error {
+	if _, ok := f.res[key]; ok {
+		return fmt.Errorf("%w: %s", errDuplicateFlatKey, key)
+	}
+
+	f.res[key] = val
+
+	return nil
+}
+
+// flatten adds the value v, which has the key key, to the result. Nested values are added with their keys
+// joined to key. Arrays of scalars are joined with ",", and the elements of other arrays are added using
+// their index as a key. Null values are skipped.
+func (f *flattener) flatten(key string, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names) // so that the same error is returned every time if two keys flatten to the same key
+
+		for _, name := range names {
+			err := f.flatten(f.key(key, name), v[name])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case []interface{}:
+		if vals, ok := scalarStrings(v); ok {
+			return f.set(key, strings.Join(vals, listValueSeparator))
+		}
+
+		for i, elem := range v {
+			err := f.flatten(f.indexKey(key, i), elem)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		s, _ := scalarString(v)
+
+		return f.set(key, s)
+	}
+}
+
+// scalarStrings converts the elements of an array to strings, if they are all scalar values.
+func scalarStrings(arr []interface{}) ([]string, bool) {
+	res := make([]string, len(arr))
+
+	for i, elem := range arr {
+		s, ok := scalarString(elem)
+		if !ok {
+			return nil, false
+		}
+
+		res[i] = s
+	}
+
+	return res, true
+}
+
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
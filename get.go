@@ -0,0 +1,89 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const keyWrapFmt = `key "%s": %w`
+
+var errKeyNotSet = errors.New("config key is not set")
+
+// Get reads a single config value and parses it into a value of type T, without declaring a struct.
+// Values are parsed exactly as they would be for a struct field of type T.
+//
+// key is written in the same way as a phnenv struct tag, so it may list aliases and tag options:
+//
+//	port, err := phnenv.Get[int]("PORT,base:16")
+//	hosts, err := phnenv.Get[[]string]("HOSTS|SERVERS,sep:;")
+//
+// Get accepts the same options as Parse, except for WithStrictPrefix and WithProvenance which only apply to structs.
+// It returns an error if the key is not set, or if its value cannot be parsed into T.
+func Get[T any](key string, opts ...Option) (T, error) {
+	var res T
+
+	err := get(key, &res, newOptions(opts))
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf(errWrapFmt, err)
+	}
+
+	return res, nil
+}
+
+// GetOr is like Get, but returns fallback if the key is not set.
+// An error is still returned if the key is set to a value which cannot be parsed into T.
+func GetOr[T any](key string, fallback T, opts ...Option) (T, error) {
+	res, err := Get[T](key, opts...)
+	if errors.Is(err, errKeyNotSet) {
+		return fallback, nil
+	}
+
+	return res, err
+}
+
+// MustGet is like Get, but panics if Get would return an error.
+// It is intended for reading config during program initialization.
+func MustGet[T any](key string, opts ...Option) T {
+	res, err := Get[T](key, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return res
+}
+
+func get(tag string, res interface{}, o options) error {
+	key, to, err := parseTag(tag)
+	if err != nil {
+		return err
+	}
+
+	ls, err := loadSources(o.sources)
+	if err != nil {
+		return err
+	}
+
+	fv := reflect.ValueOf(res).Elem()
+	fp := &fieldPlan{name: key, key: key, opts: to, set: compileSetter(fv.Type(), to)}
+
+	fc, ok, err := loadAndExpandConf(newLoader(ls, ls.keys, o), fp)
+	if err != nil {
+		return fmt.Errorf(keyWrapFmt, key, err)
+	}
+	if !ok {
+		return fmt.Errorf(keyWrapFmt, key, errKeyNotSet)
+	}
+
+	err = fp.set(fc.value, fv)
+	if err != nil {
+		return fmt.Errorf(keyWrapFmt, key, err)
+	}
+
+	if o.unset || to.Unset {
+		unsetConsumed(fc.envKeys)
+	}
+
+	return nil
+}
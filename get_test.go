@@ -0,0 +1,123 @@
+package phnenv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+var getTestSource = WithSources(Map("test", map[string]string{
+	"STR":      "hello",
+	"HEX":      "ff",
+	"SMALL":    "300",
+	"LETTER":   "字",
+	"HOSTS":    "a;b;c",
+	"TIMEOUT":  "1500",
+	"IP":       "127.0.0.1",
+	"OLD_NAME": "old",
+	"REF":      "${STR} world",
+	"BAD_INT":  "abc",
+}))
+
+func Test_Get(t *testing.T) {
+	str, err := Get[string]("STR", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", str)
+
+	hex, err := Get[int]("HEX,base:16", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, 255, hex)
+
+	letter, err := Get[rune]("LETTER,rune", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, '字', letter)
+
+	hosts, err := Get[[]string]("HOSTS,sep:;", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, hosts)
+
+	timeout, err := Get[time.Duration]("TIMEOUT", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, 1500*time.Nanosecond, timeout)
+
+	ip, err := Get[net.IP]("IP", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, net.ParseIP("127.0.0.1"), ip)
+
+	ptr, err := Get[*string]("STR", getTestSource)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hello", *ptr)
+	}
+
+	name, err := Get[string]("NEW_NAME|OLD_NAME", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, "old", name)
+
+	ref, err := Get[string]("REF", getTestSource, WithExpansion())
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", ref)
+}
+
+var test_Get_Errors = []struct {
+	name     string
+	get      func() error
+	expected string
+}{
+	{"not set", func() error { _, err := Get[string]("MISSING", getTestSource); return err }, `phnenv: key "MISSING": config key is not set`},
+	{"invalid value", func() error { _, err := Get[int]("BAD_INT", getTestSource); return err }, `phnenv: key "BAD_INT": strconv.ParseInt: parsing "abc": invalid syntax`},
+	{"bitsize", func() error { _, err := Get[int]("SMALL,bitsize:8", getTestSource); return err }, `phnenv: key "SMALL": strconv.ParseInt: parsing "300": value out of range`},
+	{"invalid tag", func() error { _, err := Get[int]("STR,unknown", getTestSource); return err }, "phnenv: unsupported struct tag option provided"},
+	{"unsupported type", func() error { _, err := Get[map[string]string]("STR", getTestSource); return err }, `phnenv: key "STR": unsupported field type`},
+	{"source error", func() error { _, err := Get[string]("STR", WithSources(errSource{})); return err }, `phnenv: source "broken": it broke`},
+}
+
+func Test_Get_Errors(t *testing.T) {
+	for _, tt := range test_Get_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.get()
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func Test_Get_MatchesParse(t *testing.T) {
+	s := struct {
+		Hex   uint8    `phnenv:"HEX,base:16,bitsize:8"`
+		Hosts []string `phnenv:"HOSTS,sep:;"`
+	}{}
+
+	err := Parse(&s, getTestSource)
+	hex, hexErr := Get[uint8]("HEX,base:16,bitsize:8", getTestSource)
+	hosts, hostsErr := Get[[]string]("HOSTS,sep:;", getTestSource)
+
+	if assert.Nil(t, err) && assert.Nil(t, hexErr) && assert.Nil(t, hostsErr) {
+		assert.Equal(t, s.Hex, hex)
+		assert.Equal(t, s.Hosts, hosts)
+	}
+}
+
+func Test_GetOr(t *testing.T) {
+	str, err := GetOr("STR", "fallback", getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", str)
+
+	missing, err := GetOr("MISSING", 8080, getTestSource)
+	assert.Nil(t, err)
+	assert.Equal(t, 8080, missing)
+
+	bad, err := GetOr("BAD_INT", 8080, getTestSource)
+	assert.Equal(t, 0, bad)
+	assert.NotNil(t, err)
+}
+
+func Test_MustGet(t *testing.T) {
+	assert.Equal(t, "hello", MustGet[string]("STR", getTestSource))
+
+	assert.PanicsWithError(t, `phnenv: key "MISSING": config key is not set`, func() {
+		MustGet[string]("MISSING", getTestSource)
+	})
+}
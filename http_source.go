@@ -0,0 +1,243 @@
+package phnenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHTTPTimeout = 10 * time.Second
+
+	// defaultMaxResponseSize is the default size limit for the body of a response.
+	defaultMaxResponseSize = 10 << 20
+
+	headerAuthorization = "Authorization"
+	headerETag          = "ETag"
+	headerIfNoneMatch   = "If-None-Match"
+	bearerPrefix        = "Bearer "
+)
+
+var (
+	errHTTPStatus       = errors.New("unexpected HTTP status")
+	errResponseTooLarge = errors.New("response body is larger than the size limit")
+)
+
+// HTTPOption configures a Source which reads config from an HTTP service, such as the Source returned by HTTP.
+type HTTPOption func(*httpOptions)
+
+type httpOptions struct {
+	ctx        context.Context
+	client     *http.Client
+	timeout    time.Duration
+	token      string
+	attempts   int
+	retryDelay time.Duration
+	flatten    []FlattenOption
+	maxSize    int64
+}
+
+func newHTTPOptions(opts []HTTPOption) httpOptions {
+	o := httpOptions{ctx: context.Background(), client: http.DefaultClient, timeout: defaultHTTPTimeout, attempts: 1, maxSize: defaultMaxResponseSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithContext sets the context used for requests. Once it is cancelled, loading the source fails.
+// The default is context.Background().
+func WithContext(ctx context.Context) HTTPOption {
+	return func(o *httpOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithHTTPClient sets the client used to make requests. The default is http.DefaultClient.
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(o *httpOptions) {
+		o.client = c
+	}
+}
+
+// WithTimeout sets the time limit for each request, including reading the response. The default is 10 seconds.
+func WithTimeout(d time.Duration) HTTPOption {
+	return func(o *httpOptions) {
+		o.timeout = d
+	}
+}
+
+// WithToken sets the token sent to authenticate requests. The HTTP source sends it as a bearer token
+// in the Authorization header, the Consul source sends it as an ACL token in the X-Consul-Token header,
+// and the Vault source sends it in the X-Vault-Token header.
+func WithToken(token string) HTTPOption {
+	return func(o *httpOptions) {
+		o.token = token
+	}
+}
+
+// WithRetry makes failed requests be retried, up to a total of attempts requests. The first retry happens
+// after delay, and the delay is doubled for each retry after that. Requests are retried if they fail
+// to get a response, or if the response has the status 429 Too Many Requests or a 5xx status.
+// By default, requests are not retried.
+func WithRetry(attempts int, delay time.Duration) HTTPOption {
+	return func(o *httpOptions) {
+		o.attempts = attempts
+		o.retryDelay = delay
+	}
+}
+
+// WithResponseKeys sets how the keys of the response are flattened. See JSONFile.
+func WithResponseKeys(opts ...FlattenOption) HTTPOption {
+	return func(o *httpOptions) {
+		o.flatten = opts
+	}
+}
+
+// WithMaxResponseSize sets the size limit in bytes for the body of each response. Loading the source fails,
+// without retrying, if a response is larger than the limit. The default is 10MiB.
+func WithMaxResponseSize(n int64) HTTPOption {
+	return func(o *httpOptions) {
+		o.maxSize = n
+	}
+}
+
+// httpResponse is a response whose body has been read.
+type httpResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// doRequest makes a GET request to url with the given headers, retrying it as configured by o.
+// The response is returned for any status which is not retried, including errors.
+func doRequest(o httpOptions, url string, header http.Header) (*httpResponse, error) {
+	delay := o.retryDelay
+
+	for attempt := 1; ; attempt++ {
+		resp, err := doAttempt(o, url, header)
+		if attempt >= o.attempts || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-o.ctx.Done():
+			return nil, o.ctx.Err()
+		}
+
+		delay *= 2
+	}
+}
+
+func doAttempt(o httpOptions, url string, header http.Header) (*httpResponse, error) {
+	ctx, cancel := context.WithTimeout(o.ctx, o.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, o.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > o.maxSize {
+		return nil, fmt.Errorf("%w of %d bytes", errResponseTooLarge, o.maxSize)
+	}
+
+	return &httpResponse{status: resp.StatusCode, header: resp.Header, body: body}, nil
+}
+
+func shouldRetry(resp *httpResponse, err error) bool {
+	if err != nil {
+		return !errors.Is(err, errResponseTooLarge)
+	}
+
+	return resp.status == http.StatusTooManyRequests || resp.status >= http.StatusInternalServerError
+}
+
+func statusError(resp *httpResponse) error {
+	return fmt.Errorf("%w: %d %s", errHTTPStatus, resp.status, http.StatusText(resp.status))
+}
+
+// HTTP returns a Source containing the values of a JSON object fetched with a GET request to url, such as
+// the response of an internal key/value service. The object is flattened in the same way as for JSONFile,
+// which can be changed with WithResponseKeys.
+//
+// The URL is requested every time the source is loaded. If the response has an ETag header, the next request
+// sends it in an If-None-Match header, and a 304 Not Modified response reuses the values from before.
+// Any other response without a 2xx status is an error.
+//
+// Requests can be configured with WithContext, WithHTTPClient, WithTimeout, WithToken, WithRetry
+// and WithMaxResponseSize. To let environment variables override the service's values, list Env() before the source in WithSources.
+func HTTP(url string, opts ...HTTPOption) Source {
+	return &httpSource{url: url, opts: newHTTPOptions(opts)}
+}
+
+type httpSource struct {
+	url  string
+	opts httpOptions
+
+	// mu guards the ETag of the last response, and the values it held.
+	mu     sync.Mutex
+	etag   string
+	values map[string]string
+}
+
+func (hs *httpSource) Name() string {
+	return redactURLString(hs.url)
+}
+
+func (hs *httpSource) Load() (map[string]string, error) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	header := http.Header{}
+	if hs.opts.token != "" {
+		header.Set(headerAuthorization, bearerPrefix+hs.opts.token)
+	}
+	if hs.etag != "" {
+		header.Set(headerIfNoneMatch, hs.etag)
+	}
+
+	resp, err := doRequest(hs.opts, hs.url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.status == http.StatusNotModified && hs.etag != "":
+	case resp.status >= 200 && resp.status < 300:
+		values, err := parseJSON(resp.body, hs.opts.flatten)
+		if err != nil {
+			return nil, err
+		}
+
+		hs.etag, hs.values = resp.header.Get(headerETag), values
+	default:
+		return nil, statusError(resp)
+	}
+
+	res := make(map[string]string, len(hs.values))
+	for k, v := range hs.values {
+		res[k] = v
+	}
+
+	return res, nil
+}
@@ -0,0 +1,144 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+const iniCommentChars = "#;"
+
+var (
+	errINISection      = errors.New("invalid section header")
+	errINIEmptySection = errors.New("section name is empty")
+	errININoEquals     = errors.New("expected key = value")
+	errINIEmptyKey     = errors.New("key is empty")
+	errINIUnterminated = errors.New("quoted value is not terminated")
+	errINITrailing     = errors.New("unexpected characters after quoted value")
+)
+
+// INIFile returns a Source containing the values in the INI file at path.
+// The file is read every time the source is loaded.
+//
+// Each line of the file is either blank, a comment starting with "#" or ";", a [section] header,
+// or a key = value pair, which may also be written as key: value. A line ending with "\" continues on the next line.
+// Values may be:
+//
+//	unquoted          surrounding whitespace is trimmed and a " #" or " ;" starts a comment
+//	'single quoted'   taken literally
+//	"double quoted"   taken literally
+//
+// Section names are used as a prefix for the keys in the section, and both are split on "." and flattened
+// in the same way as for JSONFile, so host in the section [db] has the key DB_HOST.
+// Keys before the first section have no prefix. It is an error for a key to appear more than once.
+func INIFile(path string, opts ...FlattenOption) Source {
+	return iniSource{path: path, opts: opts}
+}
+
+// INIFileFS returns a Source like INIFile which reads the INI file at path in fsys, such as an embed.FS.
+func INIFileFS(fsys fs.FS, path string, opts ...FlattenOption) Source {
+	return iniSource{fsys: fsys, path: path, opts: opts}
+}
+
+type iniSource struct {
+	fsys fs.FS
+	path string
+	opts []FlattenOption
+}
+
+func (is iniSource) Name() string {
+	return is.path
+}
+
+func (is iniSource) Load() (map[string]string, error) {
+	b, err := readFile(is.fsys, is.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseINI(string(b), is.opts)
+}
+
+func parseINI(s string, opts []FlattenOption) (map[string]string, error) {
+	f := newFlattener(opts)
+
+	section := ""
+	for _, l := range joinContinuedLines(s, iniCommentChars) {
+		err := parseINILine(f, &section, strings.TrimRight(l.text, propertiesWhitespace))
+		if err != nil {
+			return nil, fmt.Errorf(errLineWrapFmt, l.num, err)
+		}
+	}
+
+	return f.res, nil
+}
+
+// parseINILine adds the value on a line to the result, or sets section to the flattened name of a section header.
+func parseINILine(f *flattener, section *string, line string) error {
+	if strings.HasPrefix(line, "[") {
+		end := strings.IndexByte(line, ']')
+		if end < 0 || !isINIComment(line[end+1:]) {
+			return errINISection
+		}
+
+		name := strings.TrimSpace(line[1:end])
+		if name == "" {
+			return errINIEmptySection
+		}
+
+		*section = f.dottedKey("", name)
+
+		return nil
+	}
+
+	i := strings.IndexAny(line, "=:")
+	if i < 0 {
+		return errININoEquals
+	}
+
+	key := strings.TrimSpace(line[:i])
+	if key == "" {
+		return errINIEmptyKey
+	}
+
+	val, err := parseINIValue(strings.TrimSpace(line[i+1:]))
+	if err != nil {
+		return err
+	}
+
+	return f.set(f.dottedKey(*section, key), val)
+}
+
+func parseINIValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	if raw[0] == '"' || raw[0] == '\'' {
+		end := strings.IndexByte(raw[1:], raw[0])
+		if end < 0 {
+			return "", errINIUnterminated
+		}
+		if !isINIComment(raw[end+2:]) {
+			return "", errINITrailing
+		}
+
+		return raw[1 : end+1], nil
+	}
+
+	for i := 1; i < len(raw); i++ {
+		if strings.IndexByte(iniCommentChars, raw[i]) >= 0 && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			return strings.TrimSpace(raw[:i]), nil
+		}
+	}
+
+	return raw, nil
+}
+
+// isINIComment reports whether rest, which follows a section header or quoted value, is only whitespace or a comment.
+func isINIComment(rest string) bool {
+	rest = strings.TrimSpace(rest)
+
+	return rest == "" || strings.IndexByte(iniCommentChars, rest[0]) >= 0
+}
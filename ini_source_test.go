@@ -0,0 +1,98 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var test_parseINI = []struct {
+	name     string
+	input    string
+	expected map[string]string
+}{
+	{"empty file", "", map[string]string{}},
+	{"comments and blank lines", "# comment\n; other comment\n\n  ; indented\nname = app\n", map[string]string{"NAME": "app"}},
+	{"keys before sections have no prefix", "name = app\n[db]\nhost = localhost\n", map[string]string{"NAME": "app", "DB_HOST": "localhost"}},
+	{"sections", "[db]\nhost=localhost\n\n[ cache ] ; comment\nhost: redis\n", map[string]string{"DB_HOST": "localhost", "CACHE_HOST": "redis"}},
+	{"dotted sections and keys", "[db.pool]\nsize = 5\nidle.timeout = 1m\n", map[string]string{"DB_POOL_SIZE": "5", "DB_POOL_IDLE_TIMEOUT": "1m"}},
+	{"inline comments", "a = 1 ; one\nb = 2\t# two\nc = x;y#z\n", map[string]string{"A": "1", "B": "2", "C": "x;y#z"}},
+	{"quoted values", "a = \" spaced ; \"\nb = 'it \"is\"' # comment\n", map[string]string{"A": " spaced ; ", "B": "it \"is\""}},
+	{"empty value", "a =\nb = ''\n", map[string]string{"A": "", "B": ""}},
+	{"value containing equals", "url = http://x/?a=b\n", map[string]string{"URL": "http://x/?a=b"}},
+	{"line continuation", "hosts = a,\\\n   b,\\\n   c\nnext = 1\n", map[string]string{"HOSTS": "a,b,c", "NEXT": "1"}},
+	{"windows line endings", "[db]\r\nhost = localhost\r\n", map[string]string{"DB_HOST": "localhost"}},
+}
+
+func Test_parseINI(t *testing.T) {
+	for _, tt := range test_parseINI {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseINI(tt.input, nil)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func Test_parseINI_FlattenOptions(t *testing.T) {
+	res, err := parseINI("[DB]\nHost = localhost\n", []FlattenOption{WithKeyCase(LowerCase), WithKeySeparator(".")})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"db.host": "localhost"}, res)
+	}
+}
+
+var test_parseINI_Errors = []struct {
+	name        string
+	input       string
+	expectedErr error
+	expectedMsg string
+}{
+	{"unclosed section", "[db\nhost = x\n", errINISection, "line 1: invalid section header"},
+	{"text after section", "[db] x\n", errINISection, "line 1: invalid section header"},
+	{"empty section", "a = 1\n[ ]\n", errINIEmptySection, "line 2: section name is empty"},
+	{"missing equals", "a = 1\n\nb\n", errININoEquals, "line 3: expected key = value"},
+	{"empty key", "= 1\n", errINIEmptyKey, "line 1: key is empty"},
+	{"unterminated quote", "a = \"x\n", errINIUnterminated, "line 1: quoted value is not terminated"},
+	{"text after quote", "a = 'x' y\n", errINITrailing, "line 1: unexpected characters after quoted value"},
+	{"duplicate key", "[db]\nhost = a\n[db]\nhost = b\n", errDuplicateFlatKey, "line 4: more than one value has the key: DB_HOST"},
+	{"duplicate flattened key", "db_host = a\n[db]\nhost = b\n", errDuplicateFlatKey, "line 3: more than one value has the key: DB_HOST"},
+}
+
+func Test_parseINI_Errors(t *testing.T) {
+	for _, tt := range test_parseINI_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseINI(tt.input, nil)
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.expectedMsg, err.Error())
+				assert.True(t, errors.Is(err, tt.expectedErr))
+			}
+		})
+	}
+}
+
+func Test_Parse_INIFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.ini")
+	err := os.WriteFile(path, []byte("tags = a,b\n\n[db]\nhost = ini-host\nport = 5432\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := struct {
+		Host string   `phnenv:"DB_HOST"`
+		Port int      `phnenv:"DB_PORT"`
+		Tags []string `phnenv:"TAGS"`
+	}{}
+
+	err = Parse(&s, WithSources(Map("env", map[string]string{"DB_HOST": "env-host"}), INIFile(path)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "env-host", s.Host)
+		assert.Equal(t, 5432, s.Port)
+		assert.Equal(t, []string{"a", "b"}, s.Tags)
+	}
+}
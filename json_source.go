@@ -0,0 +1,82 @@
+package phnenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+var (
+	errJSONNotObject = errors.New("JSON document must be an object")
+	errJSONTrailing  = errors.New("unexpected data after JSON document")
+)
+
+// JSONFile returns a Source containing the values in the JSON file at path.
+// The file is read every time the source is loaded.
+//
+// The document must be an object. Nested objects are flattened by joining their keys with "_"
+// and converting them to upper case, so {"db": {"host": "localhost"}} has the key DB_HOST.
+// The separator and case can be changed with WithKeySeparator and WithKeyCase.
+//
+// Strings, numbers and bools are converted to the same text as they have in the document, and null values
+// are treated as not set. Arrays of these values are joined with ",", the default separator for slice fields.
+// The elements of arrays which contain objects or arrays are flattened using their index as a key,
+// so {"servers": [{"host": "a"}]} has the key SERVERS_0_HOST.
+func JSONFile(path string, opts ...FlattenOption) Source {
+	return jsonSource{path: path, opts: opts}
+}
+
+// JSONFileFS returns a Source like JSONFile which reads the JSON file at path in fsys, such as an embed.FS.
+func JSONFileFS(fsys fs.FS, path string, opts ...FlattenOption) Source {
+	return jsonSource{fsys: fsys, path: path, opts: opts}
+}
+
+type jsonSource struct {
+	fsys fs.FS
+	path string
+	opts []FlattenOption
+}
+
+func (js jsonSource) Name() string {
+	return js.path
+}
+
+func (js jsonSource) Load() (map[string]string, error) {
+	b, err := readFile(js.fsys, js.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJSON(b, js.opts)
+}
+
+func parseJSON(b []byte, opts []FlattenOption) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc interface{}
+	err := dec.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errJSONTrailing
+	}
+
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errJSONNotObject
+	}
+
+	f := newFlattener(opts)
+
+	err = f.flatten("", obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.res, nil
+}
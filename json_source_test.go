@@ -0,0 +1,104 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var test_parseJSON = []struct {
+	name     string
+	input    string
+	opts     []FlattenOption
+	expected map[string]string
+}{
+	{"empty object", `{}`, nil, map[string]string{}},
+	{"scalars", `{"name": "app", "port": 8080, "ratio": 1.5e3, "debug": true}`, nil,
+		map[string]string{"NAME": "app", "PORT": "8080", "RATIO": "1.5e3", "DEBUG": "true"}},
+	{"null is not set", `{"name": null}`, nil, map[string]string{}},
+	{"nested objects", `{"db": {"host": "localhost", "pool": {"size": 5}}}`, nil,
+		map[string]string{"DB_HOST": "localhost", "DB_POOL_SIZE": "5"}},
+	{"array of scalars", `{"tags": ["a", "b", 3, false], "empty": []}`, nil,
+		map[string]string{"TAGS": "a,b,3,false", "EMPTY": ""}},
+	{"array of objects", `{"servers": [{"host": "a"}, {"host": "b", "ports": [1, 2]}]}`, nil,
+		map[string]string{"SERVERS_0_HOST": "a", "SERVERS_1_HOST": "b", "SERVERS_1_PORTS": "1,2"}},
+	{"separator", `{"db": {"host": "localhost"}}`, []FlattenOption{WithKeySeparator(".")},
+		map[string]string{"DB.HOST": "localhost"}},
+	{"lower case", `{"DB": {"Host": "localhost"}}`, []FlattenOption{WithKeyCase(LowerCase)},
+		map[string]string{"db_host": "localhost"}},
+	{"preserve case", `{"db": {"Host": "localhost"}}`, []FlattenOption{WithKeyCase(PreserveCase), WithKeySeparator("__")},
+		map[string]string{"db__Host": "localhost"}},
+}
+
+func Test_parseJSON(t *testing.T) {
+	for _, tt := range test_parseJSON {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseJSON([]byte(tt.input), tt.opts)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+var test_parseJSON_Errors = []struct {
+	name        string
+	input       string
+	expectedErr error
+	expectedMsg string
+}{
+	{"not an object", `["a"]`, errJSONNotObject, "JSON document must be an object"},
+	{"duplicate flattened key", `{"db": {"host": "a"}, "db_host": "b"}`, errDuplicateFlatKey, "more than one value has the key: DB_HOST"},
+	{"trailing data", `{} {}`, errJSONTrailing, "unexpected data after JSON document"},
+	{"syntax error", `{"a": }`, nil, "invalid character '}' looking for beginning of value"},
+}
+
+func Test_parseJSON_Errors(t *testing.T) {
+	for _, tt := range test_parseJSON_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSON([]byte(tt.input), nil)
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.expectedMsg, err.Error())
+				if tt.expectedErr != nil {
+					assert.True(t, errors.Is(err, tt.expectedErr))
+				}
+			}
+		})
+	}
+}
+
+func Test_Parse_JSONFile_EnvTakesPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"db": {"host": "json-host", "port": 5432}, "tags": ["a", "b"]}`), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := struct {
+		Host string   `phnenv:"DB_HOST"`
+		Port int      `phnenv:"DB_PORT"`
+		Tags []string `phnenv:"TAGS"`
+	}{}
+
+	err = Parse(&s, WithSources(Map("env", map[string]string{"DB_HOST": "env-host"}), JSONFile(path)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "env-host", s.Host)
+		assert.Equal(t, 5432, s.Port)
+		assert.Equal(t, []string{"a", "b"}, s.Tags)
+	}
+}
+
+func Test_Parse_JSONFile_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	s := struct {
+		Host string `phnenv:"DB_HOST"`
+	}{}
+
+	err := Parse(&s, WithSources(JSONFile(path)))
+
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
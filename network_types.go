@@ -0,0 +1,82 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+)
+
+const errHostPortWrapFmt = "invalid host:port %q: %w"
+
+var (
+	errInvalidIP   = errors.New("invalid IP address")
+	errInvalidPort = errors.New("port must be a number between 0 and 65535")
+)
+
+// HostPort is a network address split into a host and a port, such as "localhost:8080", "[::1]:443" or ":80".
+// It can be used as a field type in structs parsed by Parse.
+// The host may be empty, a hostname, or an IP address. The port must be numeric.
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+// String joins the host and port back into a single address, in the form accepted by net.Dial and net.Listen.
+func (hp HostPort) String() string {
+	return net.JoinHostPort(hp.Host, strconv.Itoa(int(hp.Port)))
+}
+
+func init() {
+	typeSetters[reflect.TypeOf(net.IP{})] = setNetIP
+	typeSetters[reflect.TypeOf(net.IPNet{})] = setNetIPNet
+	typeSetters[reflect.TypeOf(HostPort{})] = setHostPort
+}
+
+func setNetIP(conf string, _ tagOpts, fieldVal reflect.Value) error {
+	ip := net.ParseIP(conf)
+	if ip == nil {
+		return fmt.Errorf("%w: %q", errInvalidIP, conf)
+	}
+
+	fieldVal.Set(reflect.ValueOf(ip))
+
+	return nil
+}
+
+func setNetIPNet(conf string, _ tagOpts, fieldVal reflect.Value) error {
+	_, ipNet, err := net.ParseCIDR(conf)
+	if err != nil {
+		return err
+	}
+
+	fieldVal.Set(reflect.ValueOf(*ipNet))
+
+	return nil
+}
+
+func setHostPort(conf string, _ tagOpts, fieldVal reflect.Value) error {
+	hp, err := strToHostPort(conf)
+	if err != nil {
+		return err
+	}
+
+	fieldVal.Set(reflect.ValueOf(hp))
+
+	return nil
+}
+
+func strToHostPort(s string) (HostPort, error) {
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		return HostPort{}, err
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return HostPort{}, fmt.Errorf(errHostPortWrapFmt, s, errInvalidPort)
+	}
+
+	return HostPort{Host: host, Port: uint16(port)}, nil
+}
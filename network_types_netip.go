@@ -0,0 +1,45 @@
+package phnenv
+
+import (
+	"net/netip"
+	"reflect"
+)
+
+func init() {
+	typeSetters[reflect.TypeOf(netip.Addr{})] = setNetipAddr
+	typeSetters[reflect.TypeOf(netip.AddrPort{})] = setNetipAddrPort
+	typeSetters[reflect.TypeOf(netip.Prefix{})] = setNetipPrefix
+}
+
+func setNetipAddr(conf string, _ tagOpts, fieldVal reflect.Value) error {
+	addr, err := netip.ParseAddr(conf)
+	if err != nil {
+		return err
+	}
+
+	fieldVal.Set(reflect.ValueOf(addr))
+
+	return nil
+}
+
+func setNetipAddrPort(conf string, _ tagOpts, fieldVal reflect.Value) error {
+	addrPort, err := netip.ParseAddrPort(conf)
+	if err != nil {
+		return err
+	}
+
+	fieldVal.Set(reflect.ValueOf(addrPort))
+
+	return nil
+}
+
+func setNetipPrefix(conf string, _ tagOpts, fieldVal reflect.Value) error {
+	prefix, err := netip.ParsePrefix(conf)
+	if err != nil {
+		return err
+	}
+
+	fieldVal.Set(reflect.ValueOf(prefix))
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package phnenv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"testing"
+)
+
+func Test_parse_NetipTypes_SetsValues(t *testing.T) {
+	s := struct {
+		Addr     netip.Addr       `phnenv:"ADDR"`
+		AddrPtr  *netip.Addr      `phnenv:"ADDR"`
+		AddrPort netip.AddrPort   `phnenv:"ADDR_PORT"`
+		Prefixes []netip.Prefix   `phnenv:"PREFIXES"`
+		Unset    netip.Addr       `phnenv:"UNSET"`
+		Ports    []netip.AddrPort `phnenv:"ADDR_PORT"`
+	}{}
+	g := func(s string) (string, bool) {
+		switch s {
+		case "ADDR":
+			return "fe80::1", true
+		case "ADDR_PORT":
+			return "127.0.0.1:8080", true
+		case "PREFIXES":
+			return "10.0.0.0/8,192.168.0.0/16", true
+		}
+
+		return "", false
+	}
+
+	err := parse(g, &s)
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, netip.MustParseAddr("fe80::1"), s.Addr)
+		assert.Equal(t, netip.MustParseAddr("fe80::1"), *s.AddrPtr)
+		assert.Equal(t, netip.MustParseAddrPort("127.0.0.1:8080"), s.AddrPort)
+		assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")}, s.Prefixes)
+		assert.False(t, s.Unset.IsValid())
+		assert.Len(t, s.Ports, 1)
+	}
+}
+
+var test_parse_NetipTypes_InvalidValue_ReturnsFieldError = []struct {
+	Name  string
+	Input interface{}
+	Conf  string
+}{
+	{"addr",
+		&struct {
+			F netip.Addr `phnenv:"TESTENV"`
+		}{},
+		"1.2.3"},
+	{"addr port",
+		&struct {
+			F netip.AddrPort `phnenv:"TESTENV"`
+		}{},
+		"localhost:80"},
+	{"prefix",
+		&struct {
+			F []netip.Prefix `phnenv:"TESTENV"`
+		}{},
+		"10.0.0.0/8,10.0.0.0/99"},
+}
+
+func Test_parse_NetipTypes_InvalidValue_ReturnsFieldError(t *testing.T) {
+	for _, c := range test_parse_NetipTypes_InvalidValue_ReturnsFieldError {
+		t.Run(c.Name, func(t *testing.T) {
+			err := parse(testGetter(c.Conf), c.Input)
+
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), `field "F"`)
+			}
+		})
+	}
+}
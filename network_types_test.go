@@ -0,0 +1,162 @@
+package phnenv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func testGetter(conf string) confGetter {
+	return func(s string) (string, bool) {
+		if s == "TESTENV" {
+			return conf, true
+		}
+
+		return "", false
+	}
+}
+
+func Test_parse_NetIP_SetsIP(t *testing.T) {
+	s := struct {
+		F net.IP `phnenv:"TESTENV"`
+	}{}
+
+	err := parse(testGetter("10.1.2.3"), &s)
+
+	if assert.Nil(t, err) {
+		assert.True(t, net.IPv4(10, 1, 2, 3).Equal(s.F))
+	}
+}
+
+func Test_parse_NetIPSlice_SetsAllIPs(t *testing.T) {
+	s := struct {
+		F []net.IP `phnenv:"TESTENV"`
+	}{}
+
+	err := parse(testGetter("10.1.2.3,::1"), &s)
+
+	if assert.Nil(t, err) && assert.Len(t, s.F, 2) {
+		assert.True(t, net.IPv4(10, 1, 2, 3).Equal(s.F[0]))
+		assert.True(t, net.IPv6loopback.Equal(s.F[1]))
+	}
+}
+
+func Test_parse_IPNetSlice_SetsCIDRAllowlist(t *testing.T) {
+	s := struct {
+		F []*net.IPNet `phnenv:"TESTENV"`
+	}{}
+
+	err := parse(testGetter("10.0.0.0/8,fd00::/8"), &s)
+
+	if assert.Nil(t, err) && assert.Len(t, s.F, 2) {
+		assert.Equal(t, "10.0.0.0/8", s.F[0].String())
+		assert.Equal(t, "fd00::/8", s.F[1].String())
+		assert.True(t, s.F[0].Contains(net.IPv4(10, 9, 8, 7)))
+	}
+}
+
+func Test_parse_IPNetPointer_SetsNetwork(t *testing.T) {
+	s := struct {
+		F *net.IPNet `phnenv:"TESTENV"`
+	}{}
+
+	err := parse(testGetter("192.168.1.17/24"), &s)
+
+	if assert.Nil(t, err) && assert.NotNil(t, s.F) {
+		assert.Equal(t, "192.168.1.0/24", s.F.String())
+	}
+}
+
+var test_parse_HostPort_PositiveCases = []struct {
+	Name     string
+	Conf     string
+	Expected HostPort
+}{
+	{"hostname", "localhost:8080", HostPort{Host: "localhost", Port: 8080}},
+	{"empty host", ":80", HostPort{Port: 80}},
+	{"ipv4", "127.0.0.1:443", HostPort{Host: "127.0.0.1", Port: 443}},
+	{"ipv6", "[::1]:443", HostPort{Host: "::1", Port: 443}},
+}
+
+func Test_parse_HostPort_PositiveCases(t *testing.T) {
+	for _, c := range test_parse_HostPort_PositiveCases {
+		t.Run(c.Name, func(t *testing.T) {
+			s := struct {
+				F HostPort `phnenv:"TESTENV"`
+			}{}
+
+			err := parse(testGetter(c.Conf), &s)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, c.Expected, s.F)
+				assert.Equal(t, c.Conf, s.F.String())
+			}
+		})
+	}
+}
+
+func Test_parse_HostPortSlice_SetsAll(t *testing.T) {
+	s := struct {
+		F []HostPort `phnenv:"TESTENV,sep:;"`
+	}{}
+
+	err := parse(testGetter("a:1;b:2"), &s)
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []HostPort{{"a", 1}, {"b", 2}}, s.F)
+	}
+}
+
+var test_parse_NetworkTypes_InvalidValue_ReturnsFieldError = []struct {
+	Name  string
+	Input interface{}
+	Conf  string
+}{
+	{"ip",
+		&struct {
+			F net.IP `phnenv:"TESTENV"`
+		}{},
+		"10.0.0.256"},
+	{"ip slice",
+		&struct {
+			F []net.IP `phnenv:"TESTENV"`
+		}{},
+		"10.0.0.1,nope"},
+	{"ipnet",
+		&struct {
+			F *net.IPNet `phnenv:"TESTENV"`
+		}{},
+		"10.0.0.0/33"},
+	{"ipnet without mask",
+		&struct {
+			F net.IPNet `phnenv:"TESTENV"`
+		}{},
+		"10.0.0.0"},
+	{"hostport missing port",
+		&struct {
+			F HostPort `phnenv:"TESTENV"`
+		}{},
+		"localhost"},
+	{"hostport named port",
+		&struct {
+			F HostPort `phnenv:"TESTENV"`
+		}{},
+		"localhost:http"},
+	{"hostport port overflow",
+		&struct {
+			F HostPort `phnenv:"TESTENV"`
+		}{},
+		"localhost:65536"},
+}
+
+func Test_parse_NetworkTypes_InvalidValue_ReturnsFieldError(t *testing.T) {
+	for _, c := range test_parse_NetworkTypes_InvalidValue_ReturnsFieldError {
+		t.Run(c.Name, func(t *testing.T) {
+			err := parse(testGetter(c.Conf), c.Input)
+
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), `field "F"`)
+			}
+		})
+	}
+}
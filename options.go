@@ -0,0 +1,75 @@
+package phnenv
+
+// Option configures optional behavior of Parse.
+type Option func(*options)
+
+type options struct {
+	expand          bool
+	onDeprecatedKey func(deprecatedKey string, key string)
+	sources         []Source
+	strictPrefixes  []string
+	provenance      *[]Provenance
+	allErrors       bool
+	unset           bool
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithExpansion enables variable interpolation in config values before they are parsed into fields.
+// References to other variables are looked up in the same way as fields' keys, so each one is read from
+// the earliest source which holds it, which may be a different source from the value itself:
+//
+//	$VAR, ${VAR}       the value of VAR, or "" if VAR is not set
+//	${VAR:-default}    the value of VAR, or default if VAR is not set or is empty
+//	${VAR:?message}    the value of VAR, or an error containing message if VAR is not set or is empty
+//	$$                 a literal "$"
+//
+// Referenced values and defaults are expanded recursively. Parse returns an error if a variable refers back to itself.
+// Expansion can be disabled for a single field with the `noexpand` tag option.
+func WithExpansion() Option {
+	return func(o *options) {
+		o.expand = true
+	}
+}
+
+// WithDeprecatedKeyHandler registers a function which is called whenever a field's value was read from one of
+// its aliases instead of from its main key. For example, given the tag `phnenv:"NEW_NAME|OLD_NAME"`, f is called
+// with ("OLD_NAME", "NEW_NAME") if only OLD_NAME is set. This can be used to log a warning during a migration
+// from an old environment variable name to a new one.
+func WithDeprecatedKeyHandler(f func(deprecatedKey string, key string)) Option {
+	return func(o *options) {
+		o.onDeprecatedKey = f
+	}
+}
+
+// WithStrictPrefix makes Parse return an error if the config source contains any key starting with prefix
+// which is not read by a field of the struct. This catches misspelled environment variables, which would
+// otherwise be silently ignored. The error lists each unknown key, along with the most similar known key.
+// WithStrictPrefix can be provided more than once to check several prefixes.
+func WithStrictPrefix(prefix string) Option {
+	return func(o *options) {
+		o.strictPrefixes = append(o.strictPrefixes, prefix)
+	}
+}
+
+// WithUnset makes Parse remove the environment variables read by every field from the process environment,
+// so that secrets are not passed on to child processes or included in crash dumps. A single field's variables
+// can be removed with the `unset` tag option instead.
+//
+// Variables are only removed after the whole struct has been parsed successfully, so a failed Parse leaves
+// the environment unchanged. Only the variables a field's value was read from in Env() are removed: its key
+// and any of its aliases which are set, and the variables referred to by WithExpansion. Values read from
+// other sources, such as flags or ProcessEnv, leave the environment unchanged.
+// Note that removed variables are not available to later calls to Parse, such as the reloads of a Watcher.
+func WithUnset() Option {
+	return func(o *options) {
+		o.unset = true
+	}
+}
@@ -0,0 +1,224 @@
+package phnenv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// structPlan is the result of examining the fields and phnenv tags of a struct type.
+// Plans are cached, so the reflection over a type and the parsing of its tags only happen the first time it is parsed.
+type structPlan struct {
+	fields []*fieldPlan
+
+	// keys holds every key (including aliases) read by the struct's fields and the fields of its nested structs.
+	keys map[string]bool
+}
+
+// fieldPlan describes how to fill one field of a struct.
+// Either nested or set is non-nil.
+type fieldPlan struct {
+	index int
+	name  string
+
+	// nested is the plan for a nested struct or a pointer to a struct.
+	nested *structPlan
+
+	key  string
+	opts tagOpts
+	set  fieldSetter
+}
+
+// Function for parsing a config string into a field of a type which was determined when the plan was compiled.
+type fieldSetter func(conf string, fieldVal reflect.Value) error
+
+type cachedPlan struct {
+	plan *structPlan
+	err  error
+}
+
+// planCache maps reflect.Type to the cachedPlan compiled for that struct type.
+var planCache sync.Map
+
+// planFor gets the plan for a struct type, compiling it if it is not cached yet.
+// Errors in the type's tags are cached too, so they are returned every time the type is parsed.
+func planFor(t reflect.Type) (*structPlan, error) {
+	if cp, ok := planCache.Load(t); ok {
+		return cp.(cachedPlan).plan, cp.(cachedPlan).err
+	}
+
+	sp, err := compileStructPlan(t)
+	planCache.Store(t, cachedPlan{plan: sp, err: err})
+
+	return sp, err
+}
+
+func compileStructPlan(t reflect.Type) (*structPlan, error) {
+	sp := &structPlan{keys: map[string]bool{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		fp, err := compileFieldPlan(sf)
+		if err != nil {
+			return nil, fmt.Errorf(fieldWrapFmt, sf.Name, err)
+		}
+		if fp == nil {
+			continue
+		}
+
+		fp.index = i
+		sp.fields = append(sp.fields, fp)
+		addPlanKeys(sp, fp)
+	}
+
+	return sp, nil
+}
+
+// compileFieldPlan returns the plan for a struct field, or nil if the field should be skipped.
+func compileFieldPlan(sf reflect.StructField) (*fieldPlan, error) {
+	if isStruct(sf.Type) || isStructPtr(sf.Type) {
+		nested, err := planFor(derefType(sf.Type))
+		if err != nil {
+			return nil, err
+		}
+		if len(nested.keys) < 1 {
+			return nil, nil // structs without any phnenv tags, such as time.Time or sync.Mutex, are skipped
+		}
+
+		return &fieldPlan{name: sf.Name, nested: nested}, nil
+	}
+
+	tagStr, ok := sf.Tag.Lookup(phnEnvStructTag)
+	if !ok {
+		return nil, nil // if there's no phnenv tag this is not an error, but we should skip this field
+	}
+
+	key, opts, err := parseTag(tagStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fieldPlan{name: sf.Name, key: key, opts: opts, set: compileSetter(sf.Type, opts)}, nil
+}
+
+func addPlanKeys(sp *structPlan, fp *fieldPlan) {
+	if fp.nested != nil {
+		for k := range fp.nested.keys {
+			sp.keys[k] = true
+		}
+
+		return
+	}
+
+	sp.keys[fp.key] = true
+	for _, alias := range fp.opts.Aliases {
+		sp.keys[alias] = true
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// compileSetter returns the function which parses config strings into values of type t with the tag options to.
+func compileSetter(t reflect.Type, to tagOpts) fieldSetter {
+	if ts, ok := typeSetters[t]; ok {
+		return func(conf string, fieldVal reflect.Value) error {
+			return ts(conf, to, fieldVal)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(conf string, fieldVal reflect.Value) error {
+			setBasicBool(conf, fieldVal)
+			return nil
+		}
+	case reflect.String:
+		return func(conf string, fieldVal reflect.Value) error {
+			setBasicStr(conf, fieldVal)
+			return nil
+		}
+	case reflect.Int32:
+		return func(conf string, fieldVal reflect.Value) error {
+			return setBasicInt32(conf, to, fieldVal)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int64:
+		return func(conf string, fieldVal reflect.Value) error {
+			return setBasicInt(conf, to, fieldVal)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(conf string, fieldVal reflect.Value) error {
+			return setBasicUint(conf, to, fieldVal)
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(conf string, fieldVal reflect.Value) error {
+			return setBasicFloat(conf, to, fieldVal)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		return func(conf string, fieldVal reflect.Value) error {
+			return setBasicComplex(conf, to, fieldVal)
+		}
+	case reflect.Ptr:
+		return compilePtrSetter(t, to)
+	case reflect.Slice:
+		return compileSliceSetter(t, to)
+	default:
+		return unsupportedSetter
+	}
+}
+
+func compilePtrSetter(t reflect.Type, to tagOpts) fieldSetter {
+	setElem := compileSetter(t.Elem(), to)
+
+	return func(conf string, fieldVal reflect.Value) error {
+		newPtr := reflect.New(t.Elem())
+
+		err := setElem(conf, newPtr.Elem())
+		if err != nil {
+			return err
+		}
+
+		fieldVal.Set(newPtr)
+
+		return nil
+	}
+}
+
+func compileSliceSetter(t reflect.Type, to tagOpts) fieldSetter {
+	if t.Elem().Kind() == reflect.Slice && !hasTypeSetter(t.Elem()) {
+		return unsupportedSetter
+	}
+
+	setElem := compileSetter(t.Elem(), to)
+
+	return func(conf string, fieldVal reflect.Value) error {
+		var splt []string
+		if len(conf) > 0 {
+			splt = strings.Split(conf, to.SliceSep)
+		}
+
+		res := reflect.MakeSlice(t, len(splt), len(splt))
+
+		for i := 0; i < len(splt); i++ {
+			err := setElem(splt[i], res.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+
+		fieldVal.Set(res)
+
+		return nil
+	}
+}
+
+func unsupportedSetter(string, reflect.Value) error {
+	return errUnsupportedType
+}
@@ -0,0 +1,145 @@
+package phnenv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type planTestConf struct {
+	Host     string   `phnenv:"HOST"`
+	Port     uint16   `phnenv:"PORT"`
+	Debug    bool     `phnenv:"DEBUG"`
+	Ratio    float64  `phnenv:"RATIO"`
+	Tags     []string `phnenv:"TAGS,sep:;"`
+	MaxBytes int64    `phnenv:"MAX_BYTES,bytesize"`
+	Mask     *int     `phnenv:"MASK,base:2"`
+	Untagged string
+	DB       *struct {
+		User    string `phnenv:"DB_USER|DATABASE_USER"`
+		Timeout int    `phnenv:"DB_TIMEOUT"`
+	}
+}
+
+var planTestValues = map[string]string{
+	"HOST":       "localhost",
+	"PORT":       "8080",
+	"DEBUG":      "true",
+	"RATIO":      "0.25",
+	"TAGS":       "a;b;c",
+	"MAX_BYTES":  "64MiB",
+	"MASK":       "1010",
+	"DB_USER":    "app",
+	"DB_TIMEOUT": "30",
+}
+
+func Test_planFor_SameType_ReturnsCachedPlan(t *testing.T) {
+	typ := reflect.TypeOf(planTestConf{})
+
+	first, err := planFor(typ)
+	assert.Nil(t, err)
+
+	second, err := planFor(typ)
+	assert.Nil(t, err)
+
+	assert.True(t, first == second)
+	assert.Equal(t, map[string]bool{
+		"HOST": true, "PORT": true, "DEBUG": true, "RATIO": true, "TAGS": true, "MAX_BYTES": true, "MASK": true,
+		"DB_USER": true, "DATABASE_USER": true, "DB_TIMEOUT": true,
+	}, first.keys)
+}
+
+func Test_parse_InvalidTag_ReturnsCachedErrorEveryTime(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		s := struct {
+			A struct {
+				F int `phnenv:"F,bogus"`
+			}
+		}{}
+
+		err := parse(mapGetter(nil), &s)
+
+		if assert.NotNil(t, err) {
+			assert.Equal(t, `field "A": field "F": unsupported struct tag option provided`, err.Error())
+		}
+	}
+}
+
+func Test_Parse_ConcurrentCalls_AllSucceed(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make([]error, 16)
+	results := make([]planTestConf, 16)
+
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = Parse(&results[i], WithSources(Map("test", planTestValues)))
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range errs {
+		if assert.Nil(t, errs[i]) {
+			assert.Equal(t, "localhost", results[i].Host)
+			assert.Equal(t, int64(64<<20), results[i].MaxBytes)
+			assert.Equal(t, 10, *results[i].Mask)
+			assert.Equal(t, "app", results[i].DB.User)
+		}
+	}
+}
+
+func clearPlanCache() {
+	planCache.Range(func(k, _ interface{}) bool {
+		planCache.Delete(k)
+		return true
+	})
+}
+
+// BenchmarkParse compares parsing with a cached plan against compiling the plan on every call,
+// which is equivalent to walking the type and parsing its tags every time.
+func BenchmarkParse(b *testing.B) {
+	g := mapGetter(planTestValues)
+
+	b.Run("cached plan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var s planTestConf
+			if err := parse(g, &s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("uncached plan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			clearPlanCache()
+
+			var s planTestConf
+			if err := parse(g, &s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func Test_Parse_StructsWithoutTags_AreSkipped(t *testing.T) {
+	s := struct {
+		Started time.Time
+		mu      *sync.Mutex
+		Extra   *struct{ X string }
+		Host    string `phnenv:"HOST"`
+	}{}
+
+	err := Parse(&s, WithSources(Map("test", planTestValues)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "localhost", s.Host)
+		assert.True(t, s.Started.IsZero())
+		assert.Nil(t, s.mu)
+		assert.Nil(t, s.Extra)
+	}
+}
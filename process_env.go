@@ -0,0 +1,67 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	procEnvironSep = "\x00"
+
+	errProcEnvironPermissionFmt = "%w (the environment of a process can only be read by the same user, or with the CAP_SYS_PTRACE capability)"
+)
+
+// ProcessEnv returns a Source containing the environment variables of the process with the given pid,
+// which are read from /proc/<pid>/environ every time the source is loaded. This lets the config of another
+// running process be checked with the same struct as the process itself uses:
+//
+//	err := phnenv.Parse(&conf, phnenv.WithSources(phnenv.ProcessEnv(pid)))
+//
+// The file holds the environment the process was started with, so changes the process made to its own environment
+// with functions such as os.Setenv are not included.
+//
+// ProcessEnv only works on systems with a Linux style /proc filesystem. Reading another user's processes usually
+// requires root, and the error returned when access is denied matches fs.ErrPermission with errors.Is.
+func ProcessEnv(pid int) Source {
+	return processEnvSource{path: "/proc/" + strconv.Itoa(pid) + "/environ"}
+}
+
+type processEnvSource struct {
+	path string
+}
+
+func (ps processEnvSource) Name() string {
+	return ps.path
+}
+
+func (ps processEnvSource) Load() (map[string]string, error) {
+	b, err := os.ReadFile(ps.path)
+	if errors.Is(err, fs.ErrPermission) {
+		return nil, fmt.Errorf(errProcEnvironPermissionFmt, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProcEnviron(string(b)), nil
+}
+
+// parseProcEnviron parses the NUL separated KEY=VALUE entries of a /proc/<pid>/environ file.
+func parseProcEnviron(s string) map[string]string {
+	res := map[string]string{}
+
+	for _, kv := range strings.Split(s, procEnvironSep) {
+		i := strings.Index(kv, "=")
+		if i < 1 {
+			continue // entries without a key, such as the empty string after the final NUL, are not variables
+		}
+
+		res[kv[:i]] = kv[i+1:]
+	}
+
+	return res
+}
@@ -0,0 +1,72 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var test_parseProcEnviron = []struct {
+	name     string
+	input    string
+	expected map[string]string
+}{
+	{"empty", "", map[string]string{}},
+	{"variables", "A=1\x00B=two words\x00", map[string]string{"A": "1", "B": "two words"}},
+	{"value containing equals and newlines", "A=b=c\nd\x00", map[string]string{"A": "b=c\nd"}},
+	{"empty value", "A=\x00", map[string]string{"A": ""}},
+	{"entries without a key are skipped", "=C:=C:\\\x00junk\x00A=1", map[string]string{"A": "1"}},
+}
+
+func Test_parseProcEnviron(t *testing.T) {
+	for _, tt := range test_parseProcEnviron {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseProcEnviron(tt.input))
+		})
+	}
+}
+
+func Test_ProcessEnv_CurrentProcess(t *testing.T) {
+	src := ProcessEnv(os.Getpid())
+	if _, err := os.Stat(src.Name()); err != nil {
+		t.Skip("/proc is not available:", err)
+	}
+
+	res, err := src.Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, os.Getenv("PATH"), res["PATH"]) // PATH is inherited, so it is in the environment the test started with
+	}
+}
+
+func Test_ProcessEnv_PermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any file")
+	}
+	path := filepath.Join(t.TempDir(), "environ")
+	err := os.WriteFile(path, []byte("A=1\x00"), 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = processEnvSource{path: path}.Load()
+
+	if assert.NotNil(t, err) {
+		assert.True(t, errors.Is(err, fs.ErrPermission))
+		assert.True(t, strings.HasSuffix(err.Error(), "permission denied (the environment of a process can only be read by the same user, or with the CAP_SYS_PTRACE capability)"))
+	}
+}
+
+func Test_ProcessEnv_MissingProcess(t *testing.T) {
+	if _, err := os.Stat("/proc/self/environ"); err != nil {
+		t.Skip("/proc is not available:", err)
+	}
+
+	_, err := ProcessEnv(-1).Load()
+
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
@@ -0,0 +1,198 @@
+package phnenv
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+const (
+	propertiesCommentChars = "#!"
+	propertiesWhitespace   = " \t\f"
+)
+
+// PropertiesFile returns a Source containing the values in the Java .properties file at path.
+// The file is read every time the source is loaded.
+//
+// The file is read in the same way as Java's Properties.load, except that it must be UTF-8 rather than ISO-8859-1:
+// lines starting with "#" or "!" are comments, a key is separated from its value by "=", ":" or whitespace,
+// a line ending with "\" continues on the next line, and escapes such as \t, \n and \u00e9 are replaced.
+// If a key appears more than once, the last value is used.
+//
+// Keys are split on "." and flattened in the same way as for JSONFile, so db.host has the key DB_HOST.
+func PropertiesFile(path string, opts ...FlattenOption) Source {
+	return propertiesSource{path: path, opts: opts}
+}
+
+// PropertiesFileFS returns a Source like PropertiesFile which reads the .properties file at path in fsys, such as an embed.FS.
+func PropertiesFileFS(fsys fs.FS, path string, opts ...FlattenOption) Source {
+	return propertiesSource{fsys: fsys, path: path, opts: opts}
+}
+
+type propertiesSource struct {
+	fsys fs.FS
+	path string
+	opts []FlattenOption
+}
+
+func (ps propertiesSource) Name() string {
+	return ps.path
+}
+
+func (ps propertiesSource) Load() (map[string]string, error) {
+	b, err := readFile(ps.fsys, ps.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProperties(string(b), ps.opts)
+}
+
+func parseProperties(s string, opts []FlattenOption) (map[string]string, error) {
+	f := newFlattener(opts)
+
+	for _, l := range joinContinuedLines(s, propertiesCommentChars) {
+		rawKey, rawVal := splitPropertiesLine(l.text)
+
+		key, err := unescapeProperties(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf(errLineWrapFmt, l.num, err)
+		}
+
+		val, err := unescapeProperties(rawVal)
+		if err != nil {
+			return nil, fmt.Errorf(errLineWrapFmt, l.num, err)
+		}
+
+		f.res[f.dottedKey("", key)] = val
+	}
+
+	return f.res, nil
+}
+
+// splitPropertiesLine splits a line into its key and value, which are still escaped. The key ends at the first
+// "=", ":" or whitespace which is not escaped. Whitespace and one "=" or ":" are skipped before the value.
+func splitPropertiesLine(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=:"+propertiesWhitespace, line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], propertiesWhitespace)
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], propertiesWhitespace)
+	}
+
+	return line[:end], rest
+}
+
+// unescapeProperties replaces the escapes in a .properties key or value. A backslash before any character
+// other than t, n, r, f or u is removed. UTF-16 surrogate pairs such as \ud83d\ude00 are combined.
+func unescapeProperties(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			r, err := parseUnicodeEscape(s[i-1:])
+			if err != nil {
+				return "", err
+			}
+			i += 4
+
+			if utf16.IsSurrogate(r) {
+				if low, err := parseUnicodeEscape(s[i+1:]); err == nil {
+					if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+						r = pair
+						i += 6
+					}
+				}
+			}
+
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// parseUnicodeEscape parses the \uXXXX escape at the start of s.
+func parseUnicodeEscape(s string) (rune, error) {
+	if len(s) < 6 || !strings.HasPrefix(s, "\\u") {
+		return 0, fmt.Errorf("%w: %s", errInvalidEscape, s[:minInt(6, len(s))])
+	}
+
+	r, err := strconv.ParseUint(s[2:6], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errInvalidEscape, s[:6])
+	}
+
+	return rune(r), nil
+}
+
+// logicalLine is a line of a .properties or INI file, including the lines it continues onto.
+type logicalLine struct {
+	num  int
+	text string
+}
+
+// joinContinuedLines splits s into lines, skipping blank lines and comments which start with one of commentChars.
+// A line ending with an odd number of backslashes continues on the next line. The backslash is removed, and so is
+// the whitespace at the start of the next line.
+func joinContinuedLines(s string, commentChars string) []logicalLine {
+	var res []logicalLine
+
+	var cur *logicalLine
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimLeft(strings.TrimSuffix(line, "\r"), propertiesWhitespace)
+
+		if cur == nil {
+			if line == "" || strings.IndexByte(commentChars, line[0]) >= 0 {
+				continue
+			}
+
+			res = append(res, logicalLine{num: i + 1})
+			cur = &res[len(res)-1]
+		}
+
+		trailing := len(line) - len(strings.TrimRight(line, "\\"))
+		if trailing%2 == 1 {
+			cur.text += line[:len(line)-1]
+			continue
+		}
+
+		cur.text += line
+		cur = nil
+	}
+
+	return res
+}
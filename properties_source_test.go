@@ -0,0 +1,95 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var test_parseProperties = []struct {
+	name     string
+	input    string
+	expected map[string]string
+}{
+	{"empty file", "", map[string]string{}},
+	{"comments and blank lines", "# comment\n! other comment\n\n   # indented\nname=app\n", map[string]string{"NAME": "app"}},
+	{"separators", "a=1\nb:2\nc 3\nd = 4\ne\t:\t5\nf   =6", map[string]string{"A": "1", "B": "2", "C": "3", "D": "4", "E": "5", "F": "6"}},
+	{"key without value", "empty\nempty2=", map[string]string{"EMPTY": "", "EMPTY2": ""}},
+	{"value keeps trailing whitespace and separators", "a = b = c: d  ", map[string]string{"A": "b = c: d  "}},
+	{"dotted keys", "db.host=localhost\ndb.pool.size=5", map[string]string{"DB_HOST": "localhost", "DB_POOL_SIZE": "5"}},
+	{"escaped separators in key", `my\ key\:x\=y=1`, map[string]string{"MY KEY:X=Y": "1"}},
+	{"escapes", `a=tab\there\nnew\\slash\q`, map[string]string{"A": "tab\there\nnew\\slashq"}},
+	{"unicode escapes", `a=caf\u00e9 \u00E9\ud83d\ude00`, map[string]string{"A": "café é\U0001F600"}},
+	{"line continuation", "list=a,\\\n     b,\\\n\t c\nnext=1", map[string]string{"LIST": "a,b,c", "NEXT": "1"}},
+	{"continuation is not a comment", "a=1\\\n#2", map[string]string{"A": "1#2"}},
+	{"escaped backslash at end of line", "a=1\\\\\nb=2", map[string]string{"A": "1\\", "B": "2"}},
+	{"comment does not continue", "# comment \\\na=1", map[string]string{"A": "1"}},
+	{"windows line endings", "a=1\r\nb=2\r\n", map[string]string{"A": "1", "B": "2"}},
+	{"later lines win", "a=1\na=2", map[string]string{"A": "2"}},
+}
+
+func Test_parseProperties(t *testing.T) {
+	for _, tt := range test_parseProperties {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseProperties(tt.input, nil)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func Test_parseProperties_FlattenOptions(t *testing.T) {
+	res, err := parseProperties("db.Host=localhost", []FlattenOption{WithKeyCase(PreserveCase), WithKeySeparator(".")})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"db.Host": "localhost"}, res)
+	}
+}
+
+var test_parseProperties_Errors = []struct {
+	name        string
+	input       string
+	expectedMsg string
+}{
+	{"short unicode escape", "a=1\nb=\\u12", `line 2: invalid escape sequence: \u12`},
+	{"invalid unicode escape", "a=\\u12zz", `line 1: invalid escape sequence: \u12zz`},
+	{"invalid escape in key", "\n\nk\\uxyz1=1", `line 3: invalid escape sequence: \uxyz1`},
+}
+
+func Test_parseProperties_Errors(t *testing.T) {
+	for _, tt := range test_parseProperties_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProperties(tt.input, nil)
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.expectedMsg, err.Error())
+				assert.True(t, errors.Is(err, errInvalidEscape))
+			}
+		})
+	}
+}
+
+func Test_Parse_PropertiesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.properties")
+	err := os.WriteFile(path, []byte("db.host=props-host\ndb.port=5432\ntags=a,\\\n  b\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := struct {
+		Host string   `phnenv:"DB_HOST"`
+		Port int      `phnenv:"DB_PORT"`
+		Tags []string `phnenv:"TAGS"`
+	}{}
+
+	err = Parse(&s, WithSources(Map("env", map[string]string{"DB_HOST": "env-host"}), PropertiesFile(path)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "env-host", s.Host)
+		assert.Equal(t, 5432, s.Port)
+		assert.Equal(t, []string{"a", "b"}, s.Tags)
+	}
+}
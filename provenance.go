@@ -0,0 +1,96 @@
+package phnenv
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+const (
+	// SourceDefault is the Provenance.Source of a field which was not found in any source,
+	// but already had a non-zero value before parsing.
+	SourceDefault = "default"
+
+	// SourceUnset is the Provenance.Source of a field which was not found in any source, and has the zero value.
+	SourceUnset = "unset"
+)
+
+// Provenance describes where the value of a field came from.
+type Provenance struct {
+	// Field is the path of the field in the struct, such as "DB.Host" for the Host field of a nested struct DB.
+	Field string
+
+	// Key is the key which the value was read from. This is one of the field's aliases if the value was
+	// read from an alias, and the field's main key if the value was not found.
+	Key string
+
+	// Source is the name of the source which the value was read from, or SourceDefault or SourceUnset.
+	Source string
+
+	// Value is the value as it was found in the source, before any expansion. For SourceDefault it is
+	// the field's value formatted with fmt.Sprint. The value of a field with the secret tag option is
+	// replaced with "xxxxx", as is the userinfo of the URLs in url.URL fields.
+	Value string
+}
+
+// WithProvenance makes Parse fill report with the Provenance of every field which has a phnenv tag,
+// including the fields of nested structs, in the order the fields are declared.
+// This helps to find out whether an unexpected value came from the environment, a file, or a default.
+//
+// If Parse returns an error, report only describes the fields which were parsed before the error occurred.
+func WithProvenance(report *[]Provenance) Option {
+	return func(o *options) {
+		o.provenance = report
+	}
+}
+
+func resetProvenance(ld *loader) {
+	if ld.opts.provenance != nil {
+		*ld.opts.provenance = nil
+	}
+}
+
+// recordProvenance adds the Provenance of a field to the report, if one was requested.
+// fc is nil if the field's config was not found.
+func recordProvenance(ld *loader, path string, fp *fieldPlan, fc *fieldConf, fv reflect.Value) {
+	if ld.opts.provenance == nil {
+		return
+	}
+
+	p := Provenance{Field: path, Key: fp.key}
+
+	switch {
+	case fc != nil:
+		p.Key, p.Value, p.Source = fc.key, fc.raw, fc.source
+	case fv.IsZero():
+		p.Source = SourceUnset
+	default:
+		p.Source = SourceDefault
+		p.Value = formatDefault(fv)
+	}
+
+	if fp.opts.Secret && p.Value != "" {
+		p.Value = redacted
+	} else if isURLType(fv.Type()) {
+		p.Value = redactURLList(p.Value, fp.opts.SliceSep)
+	}
+
+	*ld.opts.provenance = append(*ld.opts.provenance, p)
+}
+
+// formatDefault formats the value of a field, following pointers so that their addresses are not shown.
+func formatDefault(fv reflect.Value) string {
+	for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+		fv = fv.Elem()
+	}
+
+	if !fv.CanInterface() {
+		return ""
+	}
+
+	if u, ok := fv.Interface().(url.URL); ok {
+		return redactURL(&u)
+	}
+
+	return fmt.Sprint(fv.Interface())
+}
@@ -0,0 +1,102 @@
+package phnenv
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+type provenanceTestDB struct {
+	Host     string `phnenv:"DB_HOST"`
+	Password string `phnenv:"DB_PASSWORD,secret"`
+	Token    string `phnenv:"DB_TOKEN,secret"`
+}
+
+type provenanceTestConf struct {
+	Name     string `phnenv:"NAME|APP_NAME"`
+	Port     int    `phnenv:"PORT"`
+	LogLevel string `phnenv:"LOG_LEVEL"`
+	Timeout  *int   `phnenv:"TIMEOUT"`
+	Greeting string `phnenv:"GREETING"`
+	Ignored  string
+	DB       provenanceTestDB
+}
+
+func Test_Parse_WithProvenance(t *testing.T) {
+	timeout := 30
+	s := provenanceTestConf{LogLevel: "info", Timeout: &timeout}
+	var report []Provenance
+
+	err := Parse(&s, WithExpansion(), WithProvenance(&report), WithSources(
+		Map("env", map[string]string{"APP_NAME": "app", "DB_PASSWORD": "hunter2"}),
+		Map(".env", map[string]string{"PORT": "8080", "GREETING": "hello ${APP_NAME}", "DB_HOST": "db"}),
+	))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []Provenance{
+			{Field: "Name", Key: "APP_NAME", Source: "env", Value: "app"},
+			{Field: "Port", Key: "PORT", Source: ".env", Value: "8080"},
+			{Field: "LogLevel", Key: "LOG_LEVEL", Source: SourceDefault, Value: "info"},
+			{Field: "Timeout", Key: "TIMEOUT", Source: SourceDefault, Value: "30"},
+			{Field: "Greeting", Key: "GREETING", Source: ".env", Value: "hello ${APP_NAME}"},
+			{Field: "DB.Host", Key: "DB_HOST", Source: ".env", Value: "db"},
+			{Field: "DB.Password", Key: "DB_PASSWORD", Source: "env", Value: "xxxxx"},
+			{Field: "DB.Token", Key: "DB_TOKEN", Source: SourceUnset, Value: ""},
+		}, report)
+		assert.Equal(t, "hello app", s.Greeting)
+	}
+}
+
+func Test_Parse_WithProvenance_ReplacesPreviousReport(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A"`
+	}{}
+	report := []Provenance{{Field: "Old"}}
+
+	err := Parse(&s, WithProvenance(&report), WithSources(Map("m", map[string]string{"A": "a"})))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []Provenance{{Field: "A", Key: "A", Source: "m", Value: "a"}}, report)
+	}
+}
+
+func Test_Parse_WithProvenance_Error_ReportsFieldsBeforeError(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A"`
+		B int    `phnenv:"B"`
+		C string `phnenv:"C"`
+	}{}
+	var report []Provenance
+
+	err := Parse(&s, WithProvenance(&report), WithSources(Map("m", map[string]string{"A": "a", "B": "b", "C": "c"})))
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []Provenance{{Field: "A", Key: "A", Source: "m", Value: "a"}}, report)
+}
+
+func Test_Parse_WithProvenance_RedactsURLUserinfo(t *testing.T) {
+	s := struct {
+		DSN      url.URL    `phnenv:"DSN"`
+		Backup   *url.URL   `phnenv:"BACKUP_DSN"`
+		Replicas []*url.URL `phnenv:"REPLICA_DSNS,sep:;"`
+		Fallback url.URL    `phnenv:"FALLBACK_DSN"`
+	}{Fallback: url.URL{Scheme: "postgres", User: url.UserPassword("u", "pw"), Host: "fallback"}}
+	var report []Provenance
+
+	err := Parse(&s, WithProvenance(&report), WithSources(Map("env", map[string]string{
+		"DSN":          "postgres://u:pw@h/db",
+		"BACKUP_DSN":   "postgres://u@backup/db",
+		"REPLICA_DSNS": "postgres://u:pw@r1/db;postgres://r2/db",
+	})))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []Provenance{
+			{Field: "DSN", Key: "DSN", Source: "env", Value: "postgres://xxxxx@h/db"},
+			{Field: "Backup", Key: "BACKUP_DSN", Source: "env", Value: "postgres://xxxxx@backup/db"},
+			{Field: "Replicas", Key: "REPLICA_DSNS", Source: "env", Value: "postgres://xxxxx@r1/db;postgres://r2/db"},
+			{Field: "Fallback", Key: "FALLBACK_DSN", Source: SourceDefault, Value: "postgres://xxxxx@fallback"},
+		}, report)
+		password, _ := s.DSN.User.Password()
+		assert.Equal(t, "pw", password)
+	}
+}
@@ -0,0 +1,147 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// osExit and stderr are variables so that tests can replace them.
+var (
+	osExit           = os.Exit
+	stderr io.Writer = os.Stderr
+)
+
+// Report describes every problem found by Parse when it is called with WithAllErrors.
+// It is returned as an error, whose message is a readable summary of the problems intended to be
+// shown to the person configuring the program.
+type Report struct {
+	// Missing lists the fields with the required option whose keys are not set.
+	Missing []FieldProblem
+
+	// Invalid lists the fields whose values could not be parsed.
+	Invalid []FieldProblem
+
+	// Unknown lists the keys found by WithStrictPrefix which are not read by any field,
+	// each followed by a suggestion of the key it may have been meant to be.
+	Unknown []string
+}
+
+// FieldProblem describes a field which could not be filled.
+type FieldProblem struct {
+	// Field is the path of the field in the struct, such as "DB.Host" for the Host field of a nested struct DB.
+	Field string
+
+	// Key is the config key read by the field.
+	Key string
+
+	// Type is the Go type of the field, such as "int" or "[]string".
+	Type string
+
+	// Desc is the value of the field's desc: tag option.
+	Desc string
+
+	// Err is the reason the field could not be filled.
+	Err error
+}
+
+// WithAllErrors makes Parse keep going after a field cannot be filled, and return a *Report describing
+// the problems with every field instead of only the first one. Problems with the struct itself,
+// such as an invalid tag, are still returned as soon as they are found.
+func WithAllErrors() Option {
+	return func(o *options) {
+		o.allErrors = true
+	}
+}
+
+// MustParse calls Parse with WithAllErrors. If Parse returns an error, MustParse writes it to stderr and
+// exits the program with status 1. It is intended for reading config at the start of main:
+//
+//	var conf Config
+//	phnenv.MustParse(&conf)
+//
+// The output lists every missing, invalid and unknown variable, for example:
+//
+//	phnenv: invalid config
+//
+//	Missing variables:
+//	  DB_HOST  DB.Host  string  database host name
+//
+//	Invalid variables:
+//	  PORT  Port  int  strconv.ParseInt: parsing "abc": invalid syntax  port to listen on
+func MustParse(v interface{}, opts ...Option) {
+	err := Parse(v, append(append([]Option{}, opts...), WithAllErrors())...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		osExit(1)
+	}
+}
+
+func (r *Report) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString("invalid config")
+
+	if len(r.Missing) > 0 {
+		sb.WriteString("\n\nMissing variables:\n")
+		writeFieldProblems(&sb, r.Missing, false)
+	}
+
+	if len(r.Invalid) > 0 {
+		sb.WriteString("\n\nInvalid variables:\n")
+		writeFieldProblems(&sb, r.Invalid, true)
+	}
+
+	if len(r.Unknown) > 0 {
+		sb.WriteString("\n\nUnknown variables:")
+		for _, u := range r.Unknown {
+			sb.WriteString("\n  " + u)
+		}
+	}
+
+	return sb.String()
+}
+
+func (r *Report) empty() bool {
+	return len(r.Missing) < 1 && len(r.Invalid) < 1 && len(r.Unknown) < 1
+}
+
+func (r *Report) addField(path string, fp *fieldPlan, t reflect.Type, err error) {
+	problem := FieldProblem{Field: path, Key: fp.key, Type: t.String(), Desc: fp.opts.Desc, Err: err}
+
+	if errors.Is(err, errRequired) {
+		r.Missing = append(r.Missing, problem)
+		return
+	}
+
+	r.Invalid = append(r.Invalid, problem)
+}
+
+// writeFieldProblems writes one aligned line for each problem, without a trailing newline.
+func writeFieldProblems(sb *strings.Builder, problems []FieldProblem, withErr bool) {
+	var buf strings.Builder
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	for _, p := range problems {
+		cols := []string{p.Key, p.Field, p.Type}
+		if withErr {
+			cols = append(cols, p.Err.Error())
+		}
+		cols = append(cols, p.Desc)
+
+		fmt.Fprintf(tw, "  %s\n", strings.Join(cols, "\t"))
+	}
+
+	_ = tw.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	sb.WriteString(strings.Join(lines, "\n"))
+}
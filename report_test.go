@@ -0,0 +1,183 @@
+package phnenv
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type reportTestDB struct {
+	Host string `phnenv:"DB_HOST,required,desc:database host name"`
+	Port int    `phnenv:"DB_PORT,desc:database port, usually 5432"`
+}
+
+type reportTestConf struct {
+	Name    string   `phnenv:"APP_NAME,required"`
+	Port    int      `phnenv:"APP_PORT,desc:port to listen on"`
+	Ratio   float32  `phnenv:"APP_RATIO"`
+	Tags    []string `phnenv:"APP_TAGS,required"`
+	DB      *reportTestDB
+	Verbose bool `phnenv:"APP_VERBOSE"`
+}
+
+func Test_Parse_Required_NotSet_ReturnsError(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A,required"`
+	}{}
+
+	err := Parse(&s, WithSources(Map("m", map[string]string{})))
+
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `phnenv: field "A": required environment variable is not set`, err.Error())
+	}
+}
+
+func Test_Parse_Required_SetToEmpty_NoError(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A|B,required"`
+	}{}
+
+	err := Parse(&s, WithSources(Map("m", map[string]string{"B": ""})))
+
+	assert.Nil(t, err)
+}
+
+func Test_Parse_WithAllErrors_ReportsEveryProblem(t *testing.T) {
+	s := reportTestConf{}
+
+	err := Parse(&s, WithAllErrors(), WithStrictPrefix("APP_"), WithSources(Map("m", map[string]string{
+		"APP_PORT":    "abc",
+		"APP_RATIO":   "1e100",
+		"DB_PORT":     "x",
+		"APP_VERBOSE": "true",
+		"APP_VERBSE":  "true",
+	})))
+
+	var report *Report
+	if assert.True(t, errors.As(err, &report)) {
+		assert.Equal(t, []string{"Name", "Tags", "DB.Host"}, problemFields(report.Missing))
+		assert.Equal(t, []string{"Port", "Ratio", "DB.Port"}, problemFields(report.Invalid))
+		assert.Equal(t, []string{"APP_VERBSE (did you mean APP_VERBOSE?)"}, report.Unknown)
+		assert.Equal(t, FieldProblem{Field: "DB.Host", Key: "DB_HOST", Type: "string", Desc: "database host name", Err: errRequired}, report.Missing[2])
+		assert.True(t, s.Verbose)
+	}
+}
+
+func Test_Parse_WithAllErrors_NoProblems_ReturnsNil(t *testing.T) {
+	s := reportTestConf{}
+
+	err := Parse(&s, WithAllErrors(), WithSources(Map("m", map[string]string{
+		"APP_NAME": "app",
+		"APP_TAGS": "a",
+		"DB_HOST":  "db",
+	})))
+
+	assert.Nil(t, err)
+}
+
+func Test_Parse_WithAllErrors_InvalidTag_ReturnsError(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A,unknown"`
+	}{}
+
+	err := Parse(&s, WithAllErrors())
+
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `phnenv: field "A": unsupported struct tag option provided`, err.Error())
+	}
+}
+
+func Test_Report_Error(t *testing.T) {
+	r := &Report{
+		Missing: []FieldProblem{
+			{Field: "Name", Key: "APP_NAME", Type: "string", Err: errRequired},
+			{Field: "DB.Host", Key: "DB_HOST", Type: "string", Desc: "database host name", Err: errRequired},
+		},
+		Invalid: []FieldProblem{
+			{Field: "Port", Key: "APP_PORT", Type: "int", Desc: "port to listen on", Err: errors.New("bad port")},
+		},
+		Unknown: []string{"APP_VERBSE (did you mean APP_VERBOSE?)"},
+	}
+
+	expected := `invalid config
+
+Missing variables:
+  APP_NAME  Name     string
+  DB_HOST   DB.Host  string  database host name
+
+Invalid variables:
+  APP_PORT  Port  int  bad port  port to listen on
+
+Unknown variables:
+  APP_VERBSE (did you mean APP_VERBOSE?)`
+
+	assert.Equal(t, expected, r.Error())
+}
+
+func Test_MustParse_Error_PrintsReportAndExits(t *testing.T) {
+	var out bytes.Buffer
+	exitCode := -1
+	origStderr, origExit := stderr, osExit
+	stderr, osExit = &out, func(code int) { exitCode = code }
+	defer func() { stderr, osExit = origStderr, origExit }()
+	s := struct {
+		A string `phnenv:"A,required"`
+	}{}
+
+	MustParse(&s, WithSources(Map("m", map[string]string{})))
+
+	assert.Equal(t, 1, exitCode)
+	assert.Equal(t, "phnenv: invalid config\n\nMissing variables:\n  A  A  string\n", out.String())
+}
+
+func Test_MustParse_InvalidSecret_ValueNotPrinted(t *testing.T) {
+	var out bytes.Buffer
+	origStderr, origExit := stderr, osExit
+	stderr, osExit = &out, func(code int) {}
+	defer func() { stderr, osExit = origStderr, origExit }()
+	s := struct {
+		PIN int `phnenv:"PIN,secret,required"`
+	}{}
+
+	MustParse(&s, WithSources(Map("m", map[string]string{"PIN": "hunter2"})))
+
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.Equal(t, "phnenv: invalid config\n\nInvalid variables:\n  PIN  PIN  int  invalid value for secret field, not shown\n", out.String())
+}
+
+func Test_MustParse_Success(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A,required"`
+	}{}
+
+	MustParse(&s, WithSources(Map("m", map[string]string{"A": "a"})))
+
+	assert.Equal(t, "a", s.A)
+}
+
+func Test_MustParse_DoesNotModifyCallerOptions(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A"`
+	}{}
+	opts := make([]Option, 1, 2)
+	opts[0] = WithSources(Map("m", map[string]string{"A": "a"}))
+	opts = append(opts, WithUnset())[:1]
+
+	MustParse(&s, opts...)
+
+	spare := options{}
+	opts[:2][1](&spare)
+	assert.Equal(t, "a", s.A)
+	assert.True(t, spare.unset)
+	assert.False(t, spare.allErrors)
+}
+
+func problemFields(problems []FieldProblem) []string {
+	var res []string
+	for _, p := range problems {
+		res = append(res, p.Field)
+	}
+
+	return res
+}
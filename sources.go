@@ -0,0 +1,186 @@
+package phnenv
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	envSourceName = "env"
+
+	errSourceWrapFmt = "source %q: %w"
+)
+
+// Source provides the config values which Parse reads into struct fields,
+// such as the OS environment or the contents of a config file.
+type Source interface {
+	// Name describes the source in errors, for example "env" or the path of a file.
+	Name() string
+
+	// Load returns all of the keys and values currently held by the source.
+	// Load is called once every time the source is used by Parse.
+	Load() (map[string]string, error)
+}
+
+// WithSources sets the sources which Parse reads config values from.
+// If a key exists in more than one source, the value from the earliest source in the list is used.
+// By default, Parse reads only from Env().
+func WithSources(srcs ...Source) Option {
+	return func(o *options) {
+		o.sources = srcs
+	}
+}
+
+// Env returns a Source containing the environment variables of the current process.
+func Env() Source {
+	return envSource{}
+}
+
+type envSource struct{}
+
+func (envSource) Name() string {
+	return envSourceName
+}
+
+func (envSource) Load() (map[string]string, error) {
+	env := os.Environ()
+	res := make(map[string]string, len(env))
+
+	for _, kv := range env {
+		i := strings.Index(kv, "=")
+		if i < 1 {
+			continue // Windows has special variables such as "=C:=C:\path" which are not real environment variables
+		}
+
+		res[kv[:i]] = kv[i+1:]
+	}
+
+	return res, nil
+}
+
+// unsetConsumed removes the keys from the environment of the current process.
+func unsetConsumed(keys []string) {
+	for _, key := range keys {
+		_ = os.Unsetenv(key)
+	}
+}
+
+// Map returns a Source containing the keys and values in m.
+// The name is used to describe the source in errors.
+// This can be used to provide defaults, or for testing.
+func Map(name string, m map[string]string) Source {
+	return mapSource{name: name, values: m}
+}
+
+type mapSource struct {
+	name   string
+	values map[string]string
+}
+
+func (ms mapSource) Name() string {
+	return ms.name
+}
+
+func (ms mapSource) Load() (map[string]string, error) {
+	return ms.values, nil
+}
+
+// loadedSource is a Source whose values have been loaded.
+type loadedSource struct {
+	name   string
+	lookup confGetter
+
+	// keys lists every key in the source.
+	keys func() []string
+
+	// flags is true if the source holds command line flags, which take precedence over credentials.
+	flags bool
+
+	// env is true if the source is the process environment, whose variables can be removed by WithUnset.
+	env bool
+}
+
+// mapLoadedSource returns a loadedSource holding the values returned by a Source's Load method.
+func mapLoadedSource(name string, vals map[string]string) loadedSource {
+	return loadedSource{
+		name: name,
+		lookup: func(key string) (string, bool) {
+			v, ok := vals[key]
+			return v, ok
+		},
+		keys: func() []string {
+			res := make([]string, 0, len(vals))
+			for k := range vals {
+				res = append(res, k)
+			}
+
+			return res
+		},
+	}
+}
+
+// envLoadedSource returns a loadedSource for Env(). Values are read with os.LookupEnv rather than from a snapshot
+// of the environment, so they are found in the same way as by the rest of the program, such as ignoring case
+// on Windows. The environment is only listed when its keys are needed, such as by strict mode.
+func envLoadedSource() loadedSource {
+	return loadedSource{
+		name:   envSourceName,
+		lookup: os.LookupEnv,
+		keys: func() []string {
+			vals, _ := envSource{}.Load()
+			return mapLoadedSource(envSourceName, vals).keys()
+		},
+		env: true,
+	}
+}
+
+// loadedSources holds several loaded sources, where earlier sources take precedence.
+type loadedSources struct {
+	srcs []loadedSource
+}
+
+func loadSources(srcs []Source) (*loadedSources, error) {
+	if len(srcs) < 1 {
+		srcs = []Source{Env()}
+	}
+
+	res := &loadedSources{srcs: make([]loadedSource, len(srcs))}
+
+	for i, src := range srcs {
+		if _, ok := src.(envSource); ok {
+			res.srcs[i] = envLoadedSource()
+			continue
+		}
+
+		vals, err := src.Load()
+		if err != nil {
+			return nil, fmt.Errorf(errSourceWrapFmt, src.Name(), err)
+		}
+
+		res.srcs[i] = mapLoadedSource(src.Name(), vals)
+		_, res.srcs[i].flags = src.(*flagsSource)
+	}
+
+	return res, nil
+}
+
+// keys returns every key in every source, sorted and without duplicates.
+func (ls *loadedSources) keys() []string {
+	seen := map[string]bool{}
+	var res []string
+
+	for _, src := range ls.srcs {
+		for _, k := range src.keys() {
+			if !seen[k] {
+				seen[k] = true
+				res = append(res, k)
+			}
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
@@ -0,0 +1,225 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+type errSource struct{}
+
+func (errSource) Name() string {
+	return "broken"
+}
+
+func (errSource) Load() (map[string]string, error) {
+	return nil, errors.New("it broke")
+}
+
+func Test_Parse_DefaultSource_ReadsEnv(t *testing.T) {
+	os.Setenv("PHNENV_TEST_DEFAULT_SOURCE", "from env")
+	defer os.Unsetenv("PHNENV_TEST_DEFAULT_SOURCE")
+	s := struct {
+		F string `phnenv:"PHNENV_TEST_DEFAULT_SOURCE"`
+	}{}
+
+	err := Parse(&s)
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "from env", s.F)
+	}
+}
+
+func Test_Env_Load_ContainsEnvironment(t *testing.T) {
+	os.Setenv("PHNENV_TEST_ENV_LOAD", "a=b")
+	defer os.Unsetenv("PHNENV_TEST_ENV_LOAD")
+
+	vals, err := Env().Load()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a=b", vals["PHNENV_TEST_ENV_LOAD"])
+	}
+}
+
+func Test_Parse_WithSources_EarlierSourcesTakePrecedence(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A"`
+		B string `phnenv:"B"`
+		C string `phnenv:"C"`
+	}{C: "untouched"}
+
+	err := Parse(&s, WithSources(
+		Map("overrides", map[string]string{"A": "override"}),
+		Map("defaults", map[string]string{"A": "default a", "B": "default b"}),
+	))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "override", s.A)
+		assert.Equal(t, "default b", s.B)
+		assert.Equal(t, "untouched", s.C)
+	}
+}
+
+func Test_Parse_SourceLoadFails_ReturnsErrorNamingSource(t *testing.T) {
+	s := struct {
+		A string `phnenv:"A"`
+	}{}
+
+	err := Parse(&s, WithSources(Map("defaults", nil), errSource{}))
+
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `source "broken": it broke`)
+	}
+}
+
+func Test_loadedSources_keys_SortedWithoutDuplicates(t *testing.T) {
+	ls, err := loadSources([]Source{
+		Map("a", map[string]string{"B": "1", "A": "1"}),
+		Map("b", map[string]string{"C": "1", "A": "2"}),
+	})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"A", "B", "C"}, ls.keys())
+	}
+}
+
+func Test_loadSources_Env_LooksUpCurrentEnvironment(t *testing.T) {
+	ls, err := loadSources(nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	os.Setenv("PHNENV_TEST_LOOKUP_AFTER_LOAD", "set later")
+	defer os.Unsetenv("PHNENV_TEST_LOOKUP_AFTER_LOAD")
+
+	v, ok := ls.srcs[0].lookup("PHNENV_TEST_LOOKUP_AFTER_LOAD")
+	assert.True(t, ok)
+	assert.Equal(t, "set later", v)
+	assert.Contains(t, ls.keys(), "PHNENV_TEST_LOOKUP_AFTER_LOAD")
+}
+
+func Test_Parse_UnsetTag_RemovesVariablesAfterSuccess(t *testing.T) {
+	os.Setenv("PHNENV_TEST_UNSET_SECRET", "hunter2")
+	os.Setenv("PHNENV_TEST_UNSET_OLD_SECRET", "hunter2")
+	os.Setenv("PHNENV_TEST_UNSET_KEEP", "kept")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_SECRET")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_OLD_SECRET")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_KEEP")
+	s := struct {
+		Secret string `phnenv:"PHNENV_TEST_UNSET_SECRET|PHNENV_TEST_UNSET_OLD_SECRET,unset"`
+		Keep   string `phnenv:"PHNENV_TEST_UNSET_KEEP"`
+	}{}
+
+	err := Parse(&s)
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hunter2", s.Secret)
+		_, secretSet := os.LookupEnv("PHNENV_TEST_UNSET_SECRET")
+		_, oldSecretSet := os.LookupEnv("PHNENV_TEST_UNSET_OLD_SECRET")
+		assert.False(t, secretSet)
+		assert.False(t, oldSecretSet)
+		assert.Equal(t, "kept", os.Getenv("PHNENV_TEST_UNSET_KEEP"))
+	}
+}
+
+func Test_Parse_WithUnset_RemovesAllReadVariables(t *testing.T) {
+	os.Setenv("PHNENV_TEST_WITH_UNSET_A", "a")
+	os.Setenv("PHNENV_TEST_WITH_UNSET_B", "b")
+	defer os.Unsetenv("PHNENV_TEST_WITH_UNSET_A")
+	defer os.Unsetenv("PHNENV_TEST_WITH_UNSET_B")
+	s := struct {
+		A string `phnenv:"PHNENV_TEST_WITH_UNSET_A"`
+		B string `phnenv:"PHNENV_TEST_WITH_UNSET_B"`
+	}{}
+
+	err := Parse(&s, WithUnset())
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a", s.A)
+		assert.Equal(t, "b", s.B)
+		assert.Equal(t, "", os.Getenv("PHNENV_TEST_WITH_UNSET_A"))
+		assert.Equal(t, "", os.Getenv("PHNENV_TEST_WITH_UNSET_B"))
+	}
+}
+
+func Test_Parse_WithUnset_Error_LeavesEnvironment(t *testing.T) {
+	os.Setenv("PHNENV_TEST_UNSET_ERR_A", "a")
+	os.Setenv("PHNENV_TEST_UNSET_ERR_B", "not a number")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_ERR_A")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_ERR_B")
+	s := struct {
+		A string `phnenv:"PHNENV_TEST_UNSET_ERR_A"`
+		B int    `phnenv:"PHNENV_TEST_UNSET_ERR_B"`
+	}{}
+
+	err := Parse(&s, WithUnset())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "a", os.Getenv("PHNENV_TEST_UNSET_ERR_A"))
+	assert.Equal(t, "not a number", os.Getenv("PHNENV_TEST_UNSET_ERR_B"))
+}
+
+func Test_Parse_WithUnset_RemovesExpandedVariables(t *testing.T) {
+	os.Setenv("PHNENV_TEST_UNSET_URL", "postgres://app:${PHNENV_TEST_UNSET_PASSWORD}@db")
+	os.Setenv("PHNENV_TEST_UNSET_PASSWORD", "hunter2")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_URL")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_PASSWORD")
+	s := struct {
+		URL string `phnenv:"PHNENV_TEST_UNSET_URL"`
+	}{}
+
+	err := Parse(&s, WithUnset(), WithExpansion())
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "postgres://app:hunter2@db", s.URL)
+		_, urlSet := os.LookupEnv("PHNENV_TEST_UNSET_URL")
+		_, passwordSet := os.LookupEnv("PHNENV_TEST_UNSET_PASSWORD")
+		assert.False(t, urlSet)
+		assert.False(t, passwordSet)
+	}
+}
+
+func Test_Parse_WithUnset_OtherSource_LeavesEnvironment(t *testing.T) {
+	os.Setenv("PHNENV_TEST_UNSET_OTHER", "from-env")
+	os.Setenv("PHNENV_TEST_UNSET_OTHER_OLD", "old")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_OTHER")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_OTHER_OLD")
+	s := struct {
+		A string `phnenv:"PHNENV_TEST_UNSET_OTHER|PHNENV_TEST_UNSET_OTHER_OLD"`
+	}{}
+
+	err := Parse(&s, WithUnset(), WithSources(Map("overrides", map[string]string{"PHNENV_TEST_UNSET_OTHER": "from-map"}), Env()))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "from-map", s.A)
+		assert.Equal(t, "from-env", os.Getenv("PHNENV_TEST_UNSET_OTHER"))
+		assert.Equal(t, "old", os.Getenv("PHNENV_TEST_UNSET_OTHER_OLD"))
+	}
+}
+
+func Test_parse_WithUnset_CustomGetter_LeavesEnvironment(t *testing.T) {
+	os.Setenv("PHNENV_TEST_UNSET_GETTER", "from-env")
+	defer os.Unsetenv("PHNENV_TEST_UNSET_GETTER")
+	s := struct {
+		A string `phnenv:"PHNENV_TEST_UNSET_GETTER"`
+	}{}
+
+	err := parse(mapGetter(map[string]string{"PHNENV_TEST_UNSET_GETTER": "from-getter"}), &s, WithUnset())
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "from-getter", s.A)
+		assert.Equal(t, "from-env", os.Getenv("PHNENV_TEST_UNSET_GETTER"))
+	}
+}
+
+func Test_Get_UnsetTag_RemovesVariable(t *testing.T) {
+	os.Setenv("PHNENV_TEST_GET_UNSET", "1")
+	defer os.Unsetenv("PHNENV_TEST_GET_UNSET")
+
+	v, err := Get[int]("PHNENV_TEST_GET_UNSET,unset")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, "", os.Getenv("PHNENV_TEST_GET_UNSET"))
+}
@@ -0,0 +1,112 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var errUnknownKeys = errors.New("unknown environment variables")
+
+// checkUnknownKeys returns an error if any key in the config source which has one of the strict prefixes
+// was not used by any field of the parsed struct.
+func checkUnknownKeys(ld *loader) error {
+	if len(ld.opts.strictPrefixes) < 1 || ld.keys == nil {
+		return nil
+	}
+
+	var unknown []string
+	for _, key := range ld.keys() {
+		if !ld.knownKeys[key] && hasAnyPrefix(key, ld.opts.strictPrefixes) {
+			unknown = append(unknown, describeUnknownKey(key, ld.knownKeys))
+		}
+	}
+
+	if len(unknown) < 1 {
+		return nil
+	}
+
+	if ld.report != nil {
+		ld.report.Unknown = unknown
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errUnknownKeys, strings.Join(unknown, ", "))
+}
+
+// describeUnknownKey returns the key along with a suggestion of the known key it was most likely meant to be, if any.
+func describeUnknownKey(key string, known map[string]bool) string {
+	suggestion, ok := nearestKey(key, known)
+	if !ok {
+		return key
+	}
+
+	return fmt.Sprintf("%s (did you mean %s?)", key, suggestion)
+}
+
+// nearestKey finds the known key with the smallest edit distance to key.
+// Keys which differ from key by more than a third of its length are not considered similar enough to suggest.
+func nearestKey(key string, known map[string]bool) (string, bool) {
+	candidates := make([]string, 0, len(known))
+	for k := range known {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates) // for a deterministic result when several keys are equally near
+
+	best := ""
+	bestDist := len(key)/3 + 1
+	for _, c := range candidates {
+		d := editDistance(key, c)
+		if d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+
+	return best, len(best) > 0
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(br)]
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if hasPrefix(s, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,79 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type strictTestConf struct {
+	Timeout int    `phnenv:"BILLING_TIMEOUT"`
+	Host    string `phnenv:"BILLING_HOST|BILLING_HOSTNAME"`
+	Nested  *struct {
+		Retries int `phnenv:"BILLING_RETRIES"`
+	}
+}
+
+func Test_Parse_WithStrictPrefix_AllKeysKnown_NoError(t *testing.T) {
+	var s strictTestConf
+
+	err := Parse(&s, WithStrictPrefix("BILLING_"), WithSources(Map("test", map[string]string{
+		"BILLING_TIMEOUT":  "5",
+		"BILLING_HOSTNAME": "old",
+		"PATH":             "/bin",
+		"OTHER_TIMEOUT":    "7",
+	})))
+
+	assert.Nil(t, err)
+}
+
+func Test_Parse_WithStrictPrefix_UnknownKeys_ReturnsErrorWithSuggestions(t *testing.T) {
+	var s strictTestConf
+
+	err := Parse(&s, WithStrictPrefix("BILLING_"), WithStrictPrefix("PAY_"), WithSources(Map("test", map[string]string{
+		"BILLNG_TIMEOUT":  "5",
+		"BILLING_TIMOUT":  "5",
+		"BILLING_RETRYS":  "1",
+		"BILLING_COLOUR":  "red",
+		"PAY_HOST":        "x",
+		"BILLING_HOST":    "billing",
+		"BILLING_RETRIES": "3",
+	})))
+
+	if assert.True(t, errors.Is(err, errUnknownKeys)) {
+		assert.Equal(t, "phnenv: unknown environment variables: "+
+			"BILLING_COLOUR, "+
+			"BILLING_RETRYS (did you mean BILLING_RETRIES?), "+
+			"BILLING_TIMOUT (did you mean BILLING_TIMEOUT?), "+
+			"PAY_HOST", err.Error())
+	}
+}
+
+func Test_Parse_WithoutStrictPrefix_UnknownKeysIgnored(t *testing.T) {
+	var s strictTestConf
+
+	err := Parse(&s, WithSources(Map("test", map[string]string{"BILLING_TIMOUT": "5"})))
+
+	assert.Nil(t, err)
+}
+
+var test_editDistance = []struct {
+	A        string
+	B        string
+	Expected int
+}{
+	{"", "", 0},
+	{"abc", "", 3},
+	{"", "abc", 3},
+	{"BILLING", "BILLNG", 1},
+	{"kitten", "sitting", 3},
+	{"字字", "字", 1},
+}
+
+func Test_editDistance(t *testing.T) {
+	for _, c := range test_editDistance {
+		t.Run(c.A+"/"+c.B, func(t *testing.T) {
+			assert.Equal(t, c.Expected, editDistance(c.A, c.B))
+		})
+	}
+}
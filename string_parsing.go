@@ -2,20 +2,66 @@ package phnenv
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 var (
-	errRuneLength = errors.New("less/more than 1 rune found for rune type")
+	errRuneLength       = errors.New("less/more than 1 rune found for rune type")
+	errByteSizeSyntax   = errors.New("invalid byte size")
+	errByteSizeUnit     = errors.New("unknown byte size unit")
+	errByteSizeFraction = errors.New("byte size must be a whole number of bytes")
+	errByteSizeNegative = errors.New("byte size must not be negative for unsigned types")
+	errByteSizeRange    = errors.New("byte size is out of range for bitsize")
+	errByteSizeBitSize  = errors.New("bitsize must be between 0 and 64 for byte sizes")
+	errInvalidEscape    = errors.New("invalid escape sequence")
 )
 
-func strToInt(s string, bitsize *int, base *int) (int64, error) {
+// byteSizeUnits maps lower case byte size suffixes to their multipliers.
+// SI suffixes (k, kb, m, mb, ...) are powers of 1000 and IEC suffixes (ki, kib, mi, mib, ...) are powers of 1024.
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"e":   1e18,
+	"eb":  1e18,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+	"ei":  1 << 60,
+	"eib": 1 << 60,
+}
+
+func strToInt(s string, bitsize *int, base *int, isByteSize bool) (int64, error) {
 	bsz := 64
 	if bitsize != nil {
 		bsz = *bitsize
 	}
 
+	if isByteSize {
+		return byteSizeToInt(s, bsz)
+	}
+
 	bse := 10
 	if base != nil {
 		bse = *base
@@ -47,12 +93,16 @@ func strToFloat(s string, bitsize *int) (float64, error) {
 	return strconv.ParseFloat(s, bs)
 }
 
-func strToUint(s string, bitsize *int, base *int) (uint64, error) {
+func strToUint(s string, bitsize *int, base *int, isByteSize bool) (uint64, error) {
 	bsz := 64
 	if bitsize != nil {
 		bsz = *bitsize
 	}
 
+	if isByteSize {
+		return byteSizeToUint(s, bsz)
+	}
+
 	bse := 10
 	if base != nil {
 		bse = *base
@@ -69,3 +119,162 @@ func strToComplex(s string, bitsize *int) (complex128, error) {
 
 	return strconv.ParseComplex(s, bs)
 }
+
+// byteSizeBits checks the bitsize of a byte size, which is in the range of the int and uint types.
+// A bitsize of 0 means 64, as there is no byte size type whose size depends on the platform.
+func byteSizeBits(bitsize int) (int, error) {
+	if bitsize < 0 || bitsize > 64 {
+		return 0, errByteSizeBitSize
+	}
+	if bitsize == 0 {
+		return 64, nil
+	}
+
+	return bitsize, nil
+}
+
+func byteSizeToInt(s string, bitsize int) (int64, error) {
+	bitsize, err := byteSizeBits(bitsize)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strToByteSize(s)
+	if err != nil {
+		return 0, err
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bitsize-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return 0, errByteSizeRange
+	}
+
+	return n.Int64(), nil
+}
+
+func byteSizeToUint(s string, bitsize int) (uint64, error) {
+	bitsize, err := byteSizeBits(bitsize)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strToByteSize(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if n.Sign() < 0 {
+		return 0, errByteSizeNegative
+	}
+
+	if n.BitLen() > bitsize {
+		return 0, errByteSizeRange
+	}
+
+	return n.Uint64(), nil
+}
+
+// strToByteSize parses a human readable byte size such as "512", "64MiB", "1.5GB" or "512k" into a number of bytes.
+// Suffixes are matched ignoring case and may be separated from the number by spaces.
+func strToByteSize(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+
+	unitIdx := strings.IndexFunc(s, unicode.IsLetter)
+	if unitIdx < 0 {
+		unitIdx = len(s)
+	}
+
+	num := strings.TrimSpace(s[:unitIdx])
+	if !isDecimal(num) {
+		return nil, errByteSizeSyntax
+	}
+
+	mul, ok := byteSizeUnits[strings.ToLower(s[unitIdx:])]
+	if !ok {
+		return nil, errByteSizeUnit
+	}
+
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return nil, errByteSizeSyntax
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).SetUint64(mul)))
+	if !r.IsInt() {
+		return nil, errByteSizeFraction
+	}
+
+	return r.Num(), nil
+}
+
+// isDecimal reports whether s is an optionally signed decimal number with an optional fractional part, e.g. "-1.5".
+func isDecimal(s string) bool {
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+
+	digits := 0
+	dots := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+			dots++
+		default:
+			return false
+		}
+	}
+
+	return digits > 0 && dots <= 1
+}
+
+// quotedEscapes maps the characters which may follow a backslash in a double quoted YAML or TOML string
+// to the characters they stand for.
+var quotedEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b",
+	' ': " ", '"': "\"", '/': "/", '\\': "\\",
+}
+
+// quotedHexEscapes maps the characters which start a hexadecimal escape to the number of hex digits which follow.
+var quotedHexEscapes = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+// unescapeQuoted replaces the backslash escapes in the contents of a double quoted YAML or TOML string.
+func unescapeQuoted(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if i >= len(s) {
+			return "", errInvalidEscape
+		}
+
+		if esc, ok := quotedEscapes[s[i]]; ok {
+			sb.WriteString(esc)
+			continue
+		}
+
+		n, ok := quotedHexEscapes[s[i]]
+		if !ok || i+n >= len(s) {
+			return "", fmt.Errorf("%w: \\%s", errInvalidEscape, s[i:minInt(i+n+1, len(s))])
+		}
+
+		r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return "", fmt.Errorf("%w: \\%s", errInvalidEscape, s[i:i+1+n])
+		}
+
+		sb.WriteRune(rune(r))
+		i += n
+	}
+
+	return sb.String(), nil
+}
@@ -43,3 +43,101 @@ func Test_strToBool_ShouldReturnFalse(t *testing.T) {
 		})
 	}
 }
+
+var test_strToByteSize_ValidInput_ReturnsBytes = []struct {
+	Name     string
+	Input    string
+	Expected int64
+}{
+	{"no suffix", "512", 512},
+	{"bytes suffix", "512B", 512},
+	{"SI single letter", "512k", 512000},
+	{"SI", "2MB", 2000000},
+	{"SI fraction", "1.5GB", 1500000000},
+	{"IEC single letter", "2Ki", 2048},
+	{"IEC", "64MiB", 64 << 20},
+	{"IEC fraction", "1.5KiB", 1536},
+	{"mixed case", "1kIb", 1024},
+	{"space before suffix", "3 TB", 3000000000000},
+	{"negative", "-2k", -2000},
+	{"surrounding space", " 1GiB ", 1 << 30},
+}
+
+func Test_strToByteSize_ValidInput_ReturnsBytes(t *testing.T) {
+	for _, c := range test_strToByteSize_ValidInput_ReturnsBytes {
+		t.Run(c.Name, func(t *testing.T) {
+			res, err := strToByteSize(c.Input)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, c.Expected, res.Int64())
+			}
+		})
+	}
+}
+
+var test_strToByteSize_InvalidInput_ReturnsError = []struct {
+	Name     string
+	Input    string
+	Expected error
+}{
+	{"empty", "", errByteSizeSyntax},
+	{"only suffix", "MiB", errByteSizeSyntax},
+	{"two dots", "1.2.3k", errByteSizeSyntax},
+	{"ratio", "1/2k", errByteSizeSyntax},
+	{"unknown unit", "5 bananas", errByteSizeUnit},
+	{"exponent", "1e3", errByteSizeUnit},
+	{"fractional bytes", "1.5B", errByteSizeFraction},
+}
+
+func Test_strToByteSize_InvalidInput_ReturnsError(t *testing.T) {
+	for _, c := range test_strToByteSize_InvalidInput_ReturnsError {
+		t.Run(c.Name, func(t *testing.T) {
+			_, err := strToByteSize(c.Input)
+
+			assert.Equal(t, c.Expected, err)
+		})
+	}
+}
+
+func Test_strToUint_ByteSizeOutOfBitSize_ReturnsError(t *testing.T) {
+	bitSize := 16
+
+	_, err := strToUint("64KiB", &bitSize, nil, true)
+
+	assert.Equal(t, errByteSizeRange, err)
+}
+
+var test_strToInt_ByteSizeBitSize = []struct {
+	Name        string
+	BitSize     int
+	Input       string
+	Expected    int64
+	ExpectedErr error
+}{
+	{"zero means 64", 0, "1KiB", 1024, nil},
+	{"zero is still limited to 64 bits", 0, "100EiB", 0, errByteSizeRange},
+	{"negative", -1, "1KiB", 0, errByteSizeBitSize},
+	{"larger than 64", 128, "100EiB", 0, errByteSizeBitSize},
+}
+
+func Test_strToInt_ByteSizeBitSize(t *testing.T) {
+	for _, c := range test_strToInt_ByteSizeBitSize {
+		t.Run(c.Name, func(t *testing.T) {
+			res, err := strToInt(c.Input, &c.BitSize, nil, true)
+
+			assert.Equal(t, c.ExpectedErr, err)
+			assert.Equal(t, c.Expected, res)
+		})
+	}
+}
+
+func Test_strToUint_ByteSizeBitSize(t *testing.T) {
+	zero, large := 0, 65
+
+	res, err := strToUint("16EiB", &zero, nil, true)
+	assert.Equal(t, errByteSizeRange, err)
+	assert.Equal(t, uint64(0), res)
+
+	_, err = strToUint("1KiB", &large, nil, true)
+	assert.Equal(t, errByteSizeBitSize, err)
+}
@@ -9,10 +9,22 @@ import (
 
 const (
 	tagRune               = "rune"
+	tagByteSize           = "bytesize"
 	tagNumBase            = "base:"
 	tagNumBitSize         = "bitsize:"
 	tagSliceSep           = "sep:"
+	tagAbsURL             = "absolute"
+	tagURLSchemes         = "schemes:"
+	tagNoExpand           = "noexpand"
+	tagSecret             = "secret"
+	tagRequired           = "required"
+	tagDesc               = "desc:"
+	tagFlag               = "flag:"
+	tagUnset              = "unset"
+	tagCredential         = "credential:"
+	urlSchemeSeparator    = "|"
 	tagSeparator          = ","
+	keyAliasSeparator     = "|"
 	defaultSliceSeparator = ","
 
 	errTagBaseWrapFmt    = "base option: %w"
@@ -20,20 +32,111 @@ const (
 )
 
 var (
-	errTagMissingData      = errors.New("phnenv struct tags must contain at minimum an environment variable name")
-	errTagDuplicateRune    = errors.New("struct tag rune option must only be provided once")
-	errTagDuplicateSep     = errors.New("struct tag sep option must only be provided once")
-	errTagDuplicateBitSize = errors.New("struct tag bitsize option must only be provided once")
-	errTagDuplicateBase    = errors.New("struct tag base option must only be provided once")
-	errTagUnsupported      = errors.New("unsupported struct tag option provided")
-	errSepLength           = errors.New("slice separator must not be empty string")
+	errTagMissingData         = errors.New("phnenv struct tags must contain at minimum an environment variable name")
+	errTagEmptyAlias          = errors.New("phnenv struct tag environment variable names must not be empty")
+	errTagDuplicateRune       = errors.New("struct tag rune option must only be provided once")
+	errTagDuplicateByteSize   = errors.New("struct tag bytesize option must only be provided once")
+	errTagDuplicateSep        = errors.New("struct tag sep option must only be provided once")
+	errTagDuplicateBitSize    = errors.New("struct tag bitsize option must only be provided once")
+	errTagDuplicateBase       = errors.New("struct tag base option must only be provided once")
+	errTagDuplicateAbsURL     = errors.New("struct tag absolute option must only be provided once")
+	errTagDuplicateSchemes    = errors.New("struct tag schemes option must only be provided once")
+	errTagDuplicateNoExpand   = errors.New("struct tag noexpand option must only be provided once")
+	errTagDuplicateSecret     = errors.New("struct tag secret option must only be provided once")
+	errTagDuplicateRequired   = errors.New("struct tag required option must only be provided once")
+	errTagDuplicateFlag       = errors.New("struct tag flag option must only be provided once")
+	errTagDuplicateUnset      = errors.New("struct tag unset option must only be provided once")
+	errTagDuplicateCredential = errors.New("struct tag credential option must only be provided once")
+	errTagUnsupported         = errors.New("unsupported struct tag option provided")
+	errSepLength              = errors.New("slice separator must not be empty string")
+	errSchemesEmpty           = errors.New("schemes option must list at least one URL scheme")
+	errFlagNameEmpty          = errors.New("flag name must not be empty string")
+	errCredentialName         = errors.New(`credential name must not be empty string, "." or "..", or contain "/"`)
+	errTagByteSizeBitSize     = errors.New("struct tag bitsize option must be between 0 and 64 when bytesize is provided")
 )
 
 type tagOpts struct {
 	NumBase    *int
 	NumBitSize *int
 	IsRune     bool
+	IsByteSize bool
 	SliceSep   string
+	AbsURL     bool
+	URLSchemes []string
+	NoExpand   bool
+	Secret     bool
+	Required   bool
+	Desc       string
+	FlagName   string
+	Unset      bool
+	Credential string
+
+	// Aliases are the alternative config keys for a field, in order of precedence.
+	// They are only used if the field's main key is not set.
+	Aliases []string
+}
+
+// Tag is a parsed phnenv struct tag.
+// It is intended for tools which work with phnenv tagged structs without calling Parse,
+// such as code generators and linters.
+type Tag struct {
+	// Keys are the config keys read by the field, in order of precedence. There is always at least one key.
+	Keys []string
+
+	// Base and BitSize hold the values of the base: and bitsize: options, or nil if they were not provided.
+	Base    *int
+	BitSize *int
+
+	// Sep is the value of the sep: option, or the default slice separator if it was not provided.
+	Sep string
+
+	// Schemes holds the values of the schemes: option, in lower case.
+	Schemes []string
+
+	// The remaining fields are true if the option of the same name was provided.
+	Rune     bool
+	ByteSize bool
+	Absolute bool
+	NoExpand bool
+	Secret   bool
+	Required bool
+	Unset    bool
+
+	// Desc is the value of the desc: option, which describes the field to people configuring the program.
+	Desc string
+
+	// Flag is the value of the flag: option, or "" if it was not provided.
+	Flag string
+
+	// Credential is the value of the credential: option, or "" if it was not provided.
+	Credential string
+}
+
+// ParseTag parses and validates the value of a phnenv struct tag, such as "PORT|OLD_PORT,base:16".
+// It returns an error in the same cases where Parse would reject the tag.
+func ParseTag(tag string) (Tag, error) {
+	key, to, err := parseTag(tag)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	return Tag{
+		Keys:       append([]string{key}, to.Aliases...),
+		Base:       to.NumBase,
+		BitSize:    to.NumBitSize,
+		Sep:        to.SliceSep,
+		Schemes:    to.URLSchemes,
+		Rune:       to.IsRune,
+		ByteSize:   to.IsByteSize,
+		Absolute:   to.AbsURL,
+		NoExpand:   to.NoExpand,
+		Secret:     to.Secret,
+		Required:   to.Required,
+		Unset:      to.Unset,
+		Desc:       to.Desc,
+		Flag:       to.FlagName,
+		Credential: to.Credential,
+	}, nil
 }
 
 func defaultOpts() tagOpts {
@@ -43,14 +146,23 @@ func defaultOpts() tagOpts {
 // parseTag parses a phnenv struct tag to get:
 // 1. the config key to retrieve to populate this struct field (the string result of parseTag)
 // 2. options for parsing the config (the tagOpts struct result of parseTag)
+//
+// A tag may list several keys separated by "|" (e.g. `phnenv:"NEW_NAME|OLD_NAME"`).
+// In that case the first key is returned and the rest are set in tagOpts.Aliases.
 func parseTag(t string) (string, tagOpts, error) {
 	opts := defaultOpts()
 
-	key, strOpts, err := validateTag(t)
+	keys, strOpts, err := validateTag(t)
 	if err != nil {
 		return "", opts, err
 	}
 
+	splitKeys := strings.Split(keys, keyAliasSeparator)
+	key := splitKeys[0]
+	if len(splitKeys) > 1 {
+		opts.Aliases = splitKeys[1:]
+	}
+
 	for _, opt := range strOpts {
 		o, err := setOpt(opts, opt)
 		if err != nil {
@@ -60,6 +172,10 @@ func parseTag(t string) (string, tagOpts, error) {
 		opts = o
 	}
 
+	if opts.IsByteSize && opts.NumBitSize != nil && (*opts.NumBitSize < 0 || *opts.NumBitSize > 64) {
+		return "", opts, errTagByteSizeBitSize
+	}
+
 	return key, opts, nil
 }
 
@@ -69,24 +185,44 @@ func validateTag(t string) (string, []string, error) {
 		return "", nil, errTagMissingData
 	}
 
-	splitT := strings.Split(t, tagSeparator)
+	splitT := joinDesc(strings.Split(t, tagSeparator))
 
 	if len(splitT[0]) < 1 {
 		return "", nil, errTagMissingData
 	}
 
+	for _, key := range strings.Split(splitT[0], keyAliasSeparator) {
+		if len(key) < 1 {
+			return "", nil, errTagEmptyAlias
+		}
+	}
+
 	splitTWithoutKey := splitT[1:]
 
 	foundBase := false
 	foundRune := false
+	foundByteSize := false
 	foundBitSize := false
 	foundSep := false
+	foundAbsURL := false
+	foundSchemes := false
+	foundNoExpand := false
+	foundSecret := false
+	foundRequired := false
+	foundFlag := false
+	foundUnset := false
+	foundCredential := false
 	for _, item := range splitTWithoutKey {
 		if isTag(item, tagRune, false) {
 			if foundRune == true {
 				return "", nil, errTagDuplicateRune
 			}
 			foundRune = true
+		} else if isTag(item, tagByteSize, false) {
+			if foundByteSize == true {
+				return "", nil, errTagDuplicateByteSize
+			}
+			foundByteSize = true
 		} else if isTag(item, tagNumBase, true) {
 			if foundBase == true {
 				return "", nil, errTagDuplicateBase
@@ -102,6 +238,48 @@ func validateTag(t string) (string, []string, error) {
 				return "", nil, errTagDuplicateSep
 			}
 			foundSep = true
+		} else if isTag(item, tagAbsURL, false) {
+			if foundAbsURL == true {
+				return "", nil, errTagDuplicateAbsURL
+			}
+			foundAbsURL = true
+		} else if isTag(item, tagURLSchemes, true) {
+			if foundSchemes == true {
+				return "", nil, errTagDuplicateSchemes
+			}
+			foundSchemes = true
+		} else if isTag(item, tagNoExpand, false) {
+			if foundNoExpand == true {
+				return "", nil, errTagDuplicateNoExpand
+			}
+			foundNoExpand = true
+		} else if isTag(item, tagSecret, false) {
+			if foundSecret == true {
+				return "", nil, errTagDuplicateSecret
+			}
+			foundSecret = true
+		} else if isTag(item, tagRequired, false) {
+			if foundRequired == true {
+				return "", nil, errTagDuplicateRequired
+			}
+			foundRequired = true
+		} else if isTag(item, tagFlag, true) {
+			if foundFlag == true {
+				return "", nil, errTagDuplicateFlag
+			}
+			foundFlag = true
+		} else if isTag(item, tagUnset, false) {
+			if foundUnset == true {
+				return "", nil, errTagDuplicateUnset
+			}
+			foundUnset = true
+		} else if isTag(item, tagCredential, true) {
+			if foundCredential == true {
+				return "", nil, errTagDuplicateCredential
+			}
+			foundCredential = true
+		} else if isTag(item, tagDesc, true) {
+			continue // joinDesc made sure that desc: is the last option
 		} else {
 			return "", nil, errTagUnsupported
 		}
@@ -110,6 +288,18 @@ func validateTag(t string) (string, []string, error) {
 	return splitT[0], splitTWithoutKey, nil
 }
 
+// joinDesc rejoins the items of a tag following a desc: option, because descriptions may contain commas.
+// This means that desc: must be the last option in a tag.
+func joinDesc(items []string) []string {
+	for i := 1; i < len(items); i++ {
+		if hasPrefix(items[i], tagDesc) {
+			return append(items[:i], strings.Join(items[i:], tagSeparator))
+		}
+	}
+
+	return items
+}
+
 func isTag(val string, t string, isPrefix bool) bool {
 	if !isPrefix {
 		return val == t
@@ -124,6 +314,50 @@ func setOpt(to tagOpts, opt string) (tagOpts, error) {
 		return to, nil
 	}
 
+	if isByteSize(opt) {
+		to.IsByteSize = true
+		return to, nil
+	}
+
+	if isAbsURL(opt) {
+		to.AbsURL = true
+		return to, nil
+	}
+
+	if isNoExpand(opt) {
+		to.NoExpand = true
+		return to, nil
+	}
+
+	if isSecret(opt) {
+		to.Secret = true
+		return to, nil
+	}
+
+	if isRequired(opt) {
+		to.Required = true
+		return to, nil
+	}
+
+	if isUnset(opt) {
+		to.Unset = true
+		return to, nil
+	}
+
+	if hasPrefix(opt, tagDesc) {
+		to.Desc = opt[len(tagDesc):]
+		return to, nil
+	}
+
+	schemes, ok, err := parseSchemes(opt)
+	if err != nil {
+		return to, err
+	}
+	if ok {
+		to.URLSchemes = schemes
+		return to, nil
+	}
+
 	base, ok, err := parseBase(opt)
 	if err != nil {
 		return to, fmt.Errorf(errTagBaseWrapFmt, err)
@@ -142,6 +376,24 @@ func setOpt(to tagOpts, opt string) (tagOpts, error) {
 		return to, nil
 	}
 
+	flagName, ok, err := parseFlag(opt)
+	if err != nil {
+		return to, err
+	}
+	if ok {
+		to.FlagName = flagName
+		return to, nil
+	}
+
+	credential, ok, err := parseCredential(opt)
+	if err != nil {
+		return to, err
+	}
+	if ok {
+		to.Credential = credential
+		return to, nil
+	}
+
 	sep, ok, err := parseSep(opt)
 	if err != nil {
 		return to, err
@@ -198,6 +450,53 @@ func parseSep(s string) (string, bool, error) {
 	return sep, true, nil
 }
 
+func parseFlag(s string) (string, bool, error) {
+	if !hasPrefix(s, tagFlag) {
+		return "", false, nil
+	}
+
+	name := s[len(tagFlag):]
+
+	if len(name) < 1 {
+		return "", true, errFlagNameEmpty
+	}
+
+	return name, true, nil
+}
+
+func parseCredential(s string) (string, bool, error) {
+	if !hasPrefix(s, tagCredential) {
+		return "", false, nil
+	}
+
+	name := s[len(tagCredential):]
+
+	if len(name) < 1 || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", true, errCredentialName
+	}
+
+	return name, true, nil
+}
+
+func parseSchemes(s string) ([]string, bool, error) {
+	if !hasPrefix(s, tagURLSchemes) {
+		return nil, false, nil
+	}
+
+	var schemes []string
+	for _, scheme := range strings.Split(s[len(tagURLSchemes):], urlSchemeSeparator) {
+		if len(scheme) > 0 {
+			schemes = append(schemes, strings.ToLower(scheme))
+		}
+	}
+
+	if len(schemes) < 1 {
+		return nil, true, errSchemesEmpty
+	}
+
+	return schemes, true, nil
+}
+
 func hasPrefix(v string, prefix string) bool {
 	if len(v) >= len(prefix) {
 		return v[:len(prefix)] == prefix
@@ -209,3 +508,27 @@ func hasPrefix(v string, prefix string) bool {
 func isRune(s string) bool {
 	return s == tagRune
 }
+
+func isByteSize(s string) bool {
+	return s == tagByteSize
+}
+
+func isAbsURL(s string) bool {
+	return s == tagAbsURL
+}
+
+func isNoExpand(s string) bool {
+	return s == tagNoExpand
+}
+
+func isSecret(s string) bool {
+	return s == tagSecret
+}
+
+func isRequired(s string) bool {
+	return s == tagRequired
+}
+
+func isUnset(s string) bool {
+	return s == tagUnset
+}
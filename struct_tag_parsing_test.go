@@ -18,3 +18,105 @@ func Test_parseSep_notSepTag_returnsFalse(t *testing.T) {
 	assert.Nil(t, err)
 	assert.False(t, ok)
 }
+
+func Test_ParseTag_AllOptions_ReturnsTag(t *testing.T) {
+	tag, err := ParseTag("NEW|OLD,rune,bytesize,base:2,bitsize:8,sep:;,absolute,schemes:HTTP|https,noexpand,secret,required,flag:port,unset,credential:port,desc:the port, in hex")
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"NEW", "OLD"}, tag.Keys)
+		assert.Equal(t, 2, *tag.Base)
+		assert.Equal(t, 8, *tag.BitSize)
+		assert.Equal(t, ";", tag.Sep)
+		assert.Equal(t, []string{"http", "https"}, tag.Schemes)
+		assert.True(t, tag.Rune)
+		assert.True(t, tag.ByteSize)
+		assert.True(t, tag.Absolute)
+		assert.True(t, tag.NoExpand)
+		assert.True(t, tag.Secret)
+		assert.True(t, tag.Required)
+		assert.True(t, tag.Unset)
+		assert.Equal(t, "the port, in hex", tag.Desc)
+		assert.Equal(t, "port", tag.Flag)
+		assert.Equal(t, "port", tag.Credential)
+	}
+}
+
+func Test_ParseTag_KeyOnly_ReturnsDefaults(t *testing.T) {
+	tag, err := ParseTag("KEY")
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, Tag{Keys: []string{"KEY"}, Sep: ","}, tag)
+	}
+}
+
+func Test_ParseTag_DuplicateSecret_ReturnsError(t *testing.T) {
+	_, err := ParseTag("KEY,secret,secret")
+
+	assert.Equal(t, errTagDuplicateSecret, err)
+}
+
+var test_parseTag_Desc = []struct {
+	name         string
+	tag          string
+	expectedDesc string
+	expectedSep  string
+}{
+	{"no desc", "KEY,sep:;", "", ";"},
+	{"empty desc", "KEY,desc:", "", ","},
+	{"desc with commas", "KEY,desc:a, b,c", "a, b,c", ","},
+	{"desc containing options", "KEY,desc:use sep:; here,sep:|", "use sep:; here,sep:|", ","},
+	{"options before desc", "KEY,sep:;,desc:hello", "hello", ";"},
+}
+
+func Test_parseTag_Desc(t *testing.T) {
+	for _, tt := range test_parseTag_Desc {
+		t.Run(tt.name, func(t *testing.T) {
+			_, to, err := parseTag(tt.tag)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.expectedDesc, to.Desc)
+				assert.Equal(t, tt.expectedSep, to.SliceSep)
+			}
+		})
+	}
+}
+
+func Test_ParseTag_DuplicateRequired_ReturnsError(t *testing.T) {
+	_, err := ParseTag("KEY,required,required")
+
+	assert.Equal(t, errTagDuplicateRequired, err)
+}
+
+func Test_ParseTag_EmptyFlag_ReturnsError(t *testing.T) {
+	_, err := ParseTag("KEY,flag:")
+
+	assert.Equal(t, errFlagNameEmpty, err)
+}
+
+var test_ParseTag_Credential_Errors = []struct {
+	name        string
+	tag         string
+	expectedErr error
+}{
+	{"empty name", "KEY,credential:", errCredentialName},
+	{"name with slash", "KEY,credential:../db-password", errCredentialName},
+	{"current directory", "KEY,credential:.", errCredentialName},
+	{"parent directory", "KEY,credential:..", errCredentialName},
+	{"duplicate", "KEY,credential:a,credential:b", errTagDuplicateCredential},
+}
+
+func Test_ParseTag_Credential_Errors(t *testing.T) {
+	for _, tt := range test_ParseTag_Credential_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTag(tt.tag)
+
+			assert.Equal(t, tt.expectedErr, err)
+		})
+	}
+}
+
+func Test_ParseTag_InvalidTag_ReturnsError(t *testing.T) {
+	_, err := ParseTag("KEY,base:2,base:3")
+
+	assert.Equal(t, errTagDuplicateBase, err)
+}
@@ -0,0 +1,653 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	errTOMLExpectedKey     = errors.New("expected a key")
+	errTOMLExpectedEquals  = errors.New(`expected "=" after key`)
+	errTOMLExpectedValue   = errors.New("expected a value")
+	errTOMLExpectedNewline = errors.New("expected a new line after value")
+	errTOMLInvalidValue    = errors.New("invalid value")
+	errTOMLUnterminated    = errors.New("string is not terminated")
+	errTOMLArray           = errors.New("invalid array")
+	errTOMLInlineTable     = errors.New("invalid inline table")
+	errTOMLTableHeader     = errors.New("invalid table header")
+	errTOMLDuplicateKey    = errors.New("duplicate key")
+	errTOMLTableRedefined  = errors.New("table is already defined")
+	errTOMLNotTable        = errors.New("key is not a table")
+)
+
+var (
+	tomlBareKeyRegex  = regexp.MustCompile(`^[A-Za-z0-9_-]+`)
+	tomlIntegerRegex  = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$|^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$|^0o[0-7](_?[0-7])*$|^0b[01](_?[01])*$`)
+	tomlFloatRegex    = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$|^[+-]?(inf|nan)$`)
+	tomlDateTimeRegex = regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2}([Tt ][0-9]{2}:[0-9]{2}(:[0-9]{2}(\.[0-9]+)?)?)?|[0-9]{2}:[0-9]{2}(:[0-9]{2}(\.[0-9]+)?)?)([Zz]|[+-][0-9]{2}:[0-9]{2})?$`)
+)
+
+// TOMLFile returns a Source containing the values in the TOML file at path.
+// The file is read every time the source is loaded. Keys are flattened in the same way as for JSONFile,
+// so the value of host in the table [db] has the key DB_HOST, and the elements of an array of tables
+// such as [[servers]] are flattened using their index as a key, as in SERVERS_0_HOST.
+//
+// Tables, arrays of tables, dotted and quoted keys, inline tables, arrays, and all kinds of strings,
+// integers, floats, bools and dates are supported. Integers are converted to decimal,
+// and underscores are removed from numbers. Dates and times are kept as they are written.
+func TOMLFile(path string, opts ...FlattenOption) Source {
+	return tomlSource{path: path, opts: opts}
+}
+
+// TOMLFileFS returns a Source like TOMLFile which reads the TOML file at path in fsys, such as an embed.FS.
+func TOMLFileFS(fsys fs.FS, path string, opts ...FlattenOption) Source {
+	return tomlSource{fsys: fsys, path: path, opts: opts}
+}
+
+type tomlSource struct {
+	fsys fs.FS
+	path string
+	opts []FlattenOption
+}
+
+func (ts tomlSource) Name() string {
+	return ts.path
+}
+
+func (ts tomlSource) Load() (map[string]string, error) {
+	b, err := readFile(ts.fsys, ts.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTOML(string(b), ts.opts)
+}
+
+func parseTOML(s string, opts []FlattenOption) (map[string]string, error) {
+	p := newTOMLParser(s)
+
+	err := p.parseDocument()
+	if err != nil {
+		return nil, fmt.Errorf(errLineWrapFmt, p.line, err)
+	}
+
+	f := newFlattener(opts)
+
+	err = f.flatten("", p.root)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.res, nil
+}
+
+// tomlKind records how a table or value was defined, which decides whether it may be extended later.
+type tomlKind int
+
+const (
+	// tomlValue is a value which cannot be extended, including inline tables and arrays.
+	// It is the zero value, so values do not need to be recorded in tomlParser.kinds.
+	tomlValue tomlKind = iota
+
+	// tomlImplicit is a table created by a header for one of its sub-tables, such as a for [a.b].
+	tomlImplicit
+
+	// tomlExplicit is a table created by its own header.
+	tomlExplicit
+
+	// tomlDotted is a table created by a dotted key, such as a for a.b = 1.
+	tomlDotted
+
+	// tomlArrayOfTables is an array created by [[headers]].
+	tomlArrayOfTables
+)
+
+// tomlParser parses a TOML document, from the byte at index pos onwards.
+type tomlParser struct {
+	s    string
+	pos  int
+	line int
+
+	root map[string]interface{}
+
+	// cur is the table which key/value pairs are added to, and curID is its id in kinds.
+	cur   map[string]interface{}
+	curID string
+
+	// kinds holds the kind of every table and array of tables, by an id made from its path.
+	// The path of an element of an array of tables includes its index.
+	kinds map[string]tomlKind
+}
+
+func newTOMLParser(s string) *tomlParser {
+	root := map[string]interface{}{}
+
+	return &tomlParser{s: s, line: 1, root: root, cur: root, kinds: map[string]tomlKind{}}
+}
+
+func (p *tomlParser) parseDocument() error {
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.s) {
+			return nil
+		}
+
+		var err error
+		if p.s[p.pos] == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.cur, p.curID)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = p.expectLineEnd()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseTableHeader() error {
+	isArray := strings.HasPrefix(p.s[p.pos:], "[[")
+	if isArray {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.s[p.pos:], closing) {
+		return errTOMLTableHeader
+	}
+	p.pos += len(closing)
+
+	parent, id, err := p.headerParent(keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	id = tomlID(id, last)
+	_, exists := parent[last]
+	kind := p.kinds[id]
+
+	if isArray {
+		switch {
+		case !exists:
+			parent[last] = []interface{}{}
+			p.kinds[id] = tomlArrayOfTables
+		case kind == tomlValue:
+			return fmt.Errorf("%w: %s", errTOMLNotTable, strings.Join(keys, "."))
+		case kind != tomlArrayOfTables:
+			return fmt.Errorf("%w: %s", errTOMLTableRedefined, strings.Join(keys, "."))
+		}
+
+		table := map[string]interface{}{}
+		arr := append(parent[last].([]interface{}), table)
+		parent[last] = arr
+		p.cur, p.curID = table, tomlID(id, strconv.Itoa(len(arr)-1))
+
+		return nil
+	}
+
+	switch {
+	case !exists:
+		parent[last] = map[string]interface{}{}
+	case kind == tomlValue:
+		return fmt.Errorf("%w: %s", errTOMLNotTable, strings.Join(keys, "."))
+	case kind != tomlImplicit:
+		return fmt.Errorf("%w: %s", errTOMLTableRedefined, strings.Join(keys, "."))
+	}
+
+	p.kinds[id] = tomlExplicit
+	p.cur, p.curID = parent[last].(map[string]interface{}), id
+
+	return nil
+}
+
+// headerParent returns the table named by the keys of a table header except the last, creating tables
+// which do not exist yet. If a key is an array of tables, its last element is used.
+func (p *tomlParser) headerParent(keys []string) (map[string]interface{}, string, error) {
+	table, id := p.root, ""
+
+	for i, key := range keys {
+		id = tomlID(id, key)
+
+		v, ok := table[key]
+		if !ok {
+			next := map[string]interface{}{}
+			table[key] = next
+			p.kinds[id] = tomlImplicit
+			table = next
+			continue
+		}
+
+		switch kind := p.kinds[id]; {
+		case kind == tomlArrayOfTables:
+			arr := v.([]interface{})
+			table = arr[len(arr)-1].(map[string]interface{})
+			id = tomlID(id, strconv.Itoa(len(arr)-1))
+		case kind == tomlImplicit || kind == tomlExplicit || kind == tomlDotted:
+			table = v.(map[string]interface{})
+		default:
+			return nil, "", fmt.Errorf("%w: %s", errTOMLNotTable, strings.Join(keys[:i+1], "."))
+		}
+	}
+
+	return table, id, nil
+}
+
+// parseKeyValue parses a key/value pair and adds it to table, whose id is id.
+func (p *tomlParser) parseKeyValue(table map[string]interface{}, id string) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	p.skipSpaces()
+	if p.pos >= len(p.s) || p.s[p.pos] != '=' {
+		return errTOMLExpectedEquals
+	}
+	p.pos++
+	p.skipSpaces()
+
+	for i, key := range keys[:len(keys)-1] {
+		id = tomlID(id, key)
+
+		v, ok := table[key]
+		if !ok {
+			next := map[string]interface{}{}
+			table[key] = next
+			p.kinds[id] = tomlDotted
+			table = next
+			continue
+		}
+
+		switch p.kinds[id] {
+		case tomlDotted:
+		case tomlValue:
+			return fmt.Errorf("%w: %s", errTOMLNotTable, strings.Join(keys[:i+1], "."))
+		default:
+			return fmt.Errorf("%w: %s", errTOMLTableRedefined, strings.Join(keys[:i+1], "."))
+		}
+
+		table = v.(map[string]interface{})
+	}
+
+	last := keys[len(keys)-1]
+	id = tomlID(id, last)
+	if _, ok := table[last]; ok {
+		return fmt.Errorf("%w: %s", errTOMLDuplicateKey, strings.Join(keys, "."))
+	}
+
+	v, err := p.parseValue(id)
+	if err != nil {
+		return err
+	}
+
+	table[last] = v
+
+	return nil
+}
+
+// parseKey parses a key, which may be dotted, and the whitespace around it.
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.s) {
+			return nil, errTOMLExpectedKey
+		}
+
+		var key string
+		switch p.s[p.pos] {
+		case '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			key = tomlBareKeyRegex.FindString(p.s[p.pos:])
+			if key == "" {
+				return nil, errTOMLExpectedKey
+			}
+			p.pos += len(key)
+		}
+
+		keys = append(keys, key)
+
+		p.skipSpaces()
+		if p.pos >= len(p.s) || p.s[p.pos] != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+// parseValue parses a value. id is the id of the value, which is used for the tables inside inline tables.
+func (p *tomlParser) parseValue(id string) (interface{}, error) {
+	if p.pos >= len(p.s) {
+		return nil, errTOMLExpectedValue
+	}
+
+	switch {
+	case strings.HasPrefix(p.s[p.pos:], `"""`):
+		return p.parseMultiLineBasicString()
+	case strings.HasPrefix(p.s[p.pos:], `'''`):
+		return p.parseMultiLineLiteralString()
+	case p.s[p.pos] == '"':
+		return p.parseBasicString()
+	case p.s[p.pos] == '\'':
+		return p.parseLiteralString()
+	case p.s[p.pos] == '[':
+		return p.parseArray(id)
+	case p.s[p.pos] == '{':
+		return p.parseInlineTable(id)
+	default:
+		return p.parseBareValue()
+	}
+}
+
+// parseBareValue parses a bool, number or date.
+func (p *tomlParser) parseBareValue() (interface{}, error) {
+	end := p.pos
+	for end < len(p.s) && strings.IndexByte("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_+-.:", p.s[end]) >= 0 {
+		end++
+	}
+
+	// a date and time may be separated by a space
+	if end-p.pos == 10 && end+1 < len(p.s) && p.s[end] == ' ' && p.s[end+1] >= '0' && p.s[end+1] <= '9' {
+		end++
+		for end < len(p.s) && strings.IndexByte("0123456789+-.:Zz", p.s[end]) >= 0 {
+			end++
+		}
+	}
+
+	tok := p.s[p.pos:end]
+	if tok == "" {
+		return nil, errTOMLExpectedValue
+	}
+
+	var v interface{}
+	switch {
+	case tok == "true" || tok == "false":
+		v = tok == "true"
+	case tomlIntegerRegex.MatchString(tok):
+		i, err := strconv.ParseInt(tok, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errTOMLInvalidValue, tok)
+		}
+		v = strconv.FormatInt(i, 10)
+	case tomlFloatRegex.MatchString(tok):
+		v = strings.ReplaceAll(tok, "_", "")
+	case tomlDateTimeRegex.MatchString(tok):
+		v = tok
+	default:
+		return nil, fmt.Errorf("%w: %s", errTOMLInvalidValue, tok)
+	}
+
+	p.pos = end
+
+	return v, nil
+}
+
+func (p *tomlParser) parseArray(id string) ([]interface{}, error) {
+	p.pos++
+	res := []interface{}{}
+
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.s) {
+			return nil, errTOMLArray
+		}
+		if p.s[p.pos] == ']' {
+			p.pos++
+			return res, nil
+		}
+
+		v, err := p.parseValue(tomlID(id, strconv.Itoa(len(res))))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+
+		p.skipBlank()
+		if p.pos >= len(p.s) {
+			return nil, errTOMLArray
+		}
+
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return res, nil
+		default:
+			return nil, errTOMLArray
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable(id string) (map[string]interface{}, error) {
+	p.pos++
+	res := map[string]interface{}{}
+
+	p.skipSpaces()
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return res, nil
+	}
+
+	for {
+		err := p.parseKeyValue(res, id)
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpaces()
+		if p.pos >= len(p.s) {
+			return nil, errTOMLInlineTable
+		}
+
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return res, nil
+		default:
+			return nil, errTOMLInlineTable
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	for i := p.pos + 1; i < len(p.s) && p.s[i] != '\n'; i++ {
+		switch p.s[i] {
+		case '\\':
+			i++
+		case '"':
+			s, err := unescapeQuoted(p.s[p.pos+1 : i])
+			if err != nil {
+				return "", err
+			}
+
+			p.pos = i + 1
+
+			return s, nil
+		}
+	}
+
+	return "", errTOMLUnterminated
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	end := strings.IndexAny(p.s[p.pos+1:], "'\n")
+	if end < 0 || p.s[p.pos+1+end] != '\'' {
+		return "", errTOMLUnterminated
+	}
+
+	s := p.s[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+
+	return s, nil
+}
+
+func (p *tomlParser) parseMultiLineBasicString() (string, error) {
+	start := p.pos + 3
+
+	var sb strings.Builder
+	for i := start; i < len(p.s); i++ {
+		switch {
+		case p.s[i] == '\\' && isTOMLLineEndingBackslash(p.s[i+1:]):
+			// a backslash at the end of a line removes the line break and the whitespace after it
+			i++
+			for i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[i]) >= 0 {
+				i++
+			}
+			i--
+		case p.s[i] == '\\' && i+1 < len(p.s):
+			sb.WriteString(p.s[i : i+2])
+			i++
+		case strings.HasPrefix(p.s[i:], `"""`):
+			end := closingTOMLQuotes(p.s, i, '"')
+			sb.WriteString(p.s[i : end-3])
+
+			s, err := unescapeQuoted(trimTOMLFirstNewline(sb.String()))
+			if err != nil {
+				return "", err
+			}
+
+			p.advance(end)
+
+			return s, nil
+		default:
+			sb.WriteByte(p.s[i])
+		}
+	}
+
+	return "", errTOMLUnterminated
+}
+
+func (p *tomlParser) parseMultiLineLiteralString() (string, error) {
+	start := p.pos + 3
+
+	end := strings.Index(p.s[start:], `'''`)
+	if end < 0 {
+		return "", errTOMLUnterminated
+	}
+	end = closingTOMLQuotes(p.s, start+end, '\'')
+
+	s := trimTOMLFirstNewline(p.s[start : end-3])
+	p.advance(end)
+
+	return s, nil
+}
+
+// closingTOMLQuotes returns the index after the closing quotes of a multi-line string, which start at s[i].
+// Up to two quotes may come directly before the closing quotes, and are part of the string.
+func closingTOMLQuotes(s string, i int, quote byte) int {
+	end := i
+	for end < len(s) && end-i < 5 && s[end] == quote {
+		end++
+	}
+
+	return end
+}
+
+// isTOMLLineEndingBackslash reports whether s, which follows a backslash, is only whitespace up to the end of the line.
+func isTOMLLineEndingBackslash(s string) bool {
+	i := strings.IndexByte(s, '\n')
+
+	return i >= 0 && strings.Trim(s[:i], " \t\r") == ""
+}
+
+// trimTOMLFirstNewline removes the line break directly after the opening quotes of a multi-line string.
+func trimTOMLFirstNewline(s string) string {
+	if strings.HasPrefix(s, "\r\n") {
+		return s[2:]
+	}
+
+	return strings.TrimPrefix(s, "\n")
+}
+
+// advance moves to the byte at index end, counting the lines passed over.
+func (p *tomlParser) advance(end int) {
+	p.line += strings.Count(p.s[p.pos:end], "\n")
+	p.pos = end
+}
+
+func (p *tomlParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, line breaks and comments.
+func (p *tomlParser) skipBlank() {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	end := strings.IndexByte(p.s[p.pos:], '\n')
+	if end < 0 {
+		p.pos = len(p.s)
+		return
+	}
+
+	p.pos += end
+}
+
+// expectLineEnd skips whitespace and a comment, and returns an error unless they are followed by a line break.
+func (p *tomlParser) expectLineEnd() error {
+	p.skipSpaces()
+	if p.pos < len(p.s) && p.s[p.pos] == '#' {
+		p.skipComment()
+	}
+	if strings.HasPrefix(p.s[p.pos:], "\r\n") {
+		p.pos++
+	}
+	if p.pos < len(p.s) && p.s[p.pos] != '\n' {
+		return errTOMLExpectedNewline
+	}
+
+	return nil
+}
+
+func tomlID(parent string, key string) string {
+	return parent + "\x00" + key
+}
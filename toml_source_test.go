@@ -0,0 +1,127 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var test_parseTOML = []struct {
+	name     string
+	input    string
+	expected map[string]string
+}{
+	{"empty document", "", map[string]string{}},
+	{"only comments", "# config\n\n  # more\n", map[string]string{}},
+	{"basic types", "name = \"app\"\nport = 8080\nratio = 1.5e3\ndebug = true\nlocal = false\n",
+		map[string]string{"NAME": "app", "PORT": "8080", "RATIO": "1.5e3", "DEBUG": "true", "LOCAL": "false"}},
+	{"integers", "a = +1_000\nb = 0xff\nc = 0o17\nd = 0b101\ne = -0\n",
+		map[string]string{"A": "1000", "B": "255", "C": "15", "D": "5", "E": "0"}},
+	{"floats", "a = 1_000.5\nb = -inf\nc = nan\nd = 5e+2\n", map[string]string{"A": "1000.5", "B": "-inf", "C": "nan", "D": "5e+2"}},
+	{"dates", "a = 1979-05-27T07:32:00Z\nb = 1979-05-27 07:32:00.999-07:00\nc = 1979-05-27\nd = 07:32:00\n",
+		map[string]string{"A": "1979-05-27T07:32:00Z", "B": "1979-05-27 07:32:00.999-07:00", "C": "1979-05-27", "D": "07:32:00"}},
+	{"strings", "a = \"tab\\there \\\"q\\\" \\u00e9\"\nb = 'C:\\path # not a comment'\nc = \"\" # comment\n",
+		map[string]string{"A": "tab\there \"q\" \u00e9", "B": "C:\\path # not a comment", "C": ""}},
+	{"multi-line strings", "a = \"\"\"\nline 1\nline 2\"\"\"\nb = '''\nraw \\n'''\nc = \"\"\"one \\\n    two\"\"\"\nd = \"\"\"\"quoted\"\"\"\"\n",
+		map[string]string{"A": "line 1\nline 2", "B": "raw \\n", "C": "one two", "D": "\"quoted\""}},
+	{"keys", "bare-key_1 = 1\n\"quoted key\" = 2\n'literal' = 3\na . b = 4\n",
+		map[string]string{"BARE-KEY_1": "1", "QUOTED KEY": "2", "LITERAL": "3", "A_B": "4"}},
+	{"tables", "name = \"app\"\n\n[db]\nhost = \"localhost\"\n\n[db.pool]\nsize = 5\n",
+		map[string]string{"NAME": "app", "DB_HOST": "localhost", "DB_POOL_SIZE": "5"}},
+	{"implicit table defined later", "[a.b]\nc = 1\n[a]\nd = 2\n", map[string]string{"A_B_C": "1", "A_D": "2"}},
+	{"sub-table of dotted keys", "[a]\nb.c = 1\n[a.b.d]\ne = 2\n", map[string]string{"A_B_C": "1", "A_B_D_E": "2"}},
+	{"arrays", "tags = [\"a\", 'b', 3]\nempty = []\nmulti = [\n  1, # one\n  2,\n]\nnested = [[1, 2], [\"x\"]]\n",
+		map[string]string{"TAGS": "a,b,3", "EMPTY": "", "MULTI": "1,2", "NESTED_0": "1,2", "NESTED_1": "x"}},
+	{"inline tables", "db = { host = \"localhost\", pool.size = 5 }\nempty = {}\n",
+		map[string]string{"DB_HOST": "localhost", "DB_POOL_SIZE": "5"}},
+	{"arrays of tables", "[[servers]]\nhost = \"a\"\n\n[servers.tls]\ncert = \"c\"\n\n[[servers]]\nhost = \"b\"\n",
+		map[string]string{"SERVERS_0_HOST": "a", "SERVERS_0_TLS_CERT": "c", "SERVERS_1_HOST": "b"}},
+	{"windows line endings", "[db]\r\nhost = \"localhost\"\r\n", map[string]string{"DB_HOST": "localhost"}},
+}
+
+func Test_parseTOML(t *testing.T) {
+	for _, tt := range test_parseTOML {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseTOML(tt.input, nil)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func Test_parseTOML_FlattenOptions(t *testing.T) {
+	res, err := parseTOML("[db]\nHost = \"localhost\"\n", []FlattenOption{WithKeyCase(PreserveCase), WithKeySeparator(".")})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"db.Host": "localhost"}, res)
+	}
+}
+
+var test_parseTOML_Errors = []struct {
+	name        string
+	input       string
+	expectedErr error
+	expectedMsg string
+}{
+	{"missing equals", "a = 1\nb 2\n", errTOMLExpectedEquals, `line 2: expected "=" after key`},
+	{"missing key", "= 1\n", errTOMLExpectedKey, "line 1: expected a key"},
+	{"missing value", "a =\n", errTOMLExpectedValue, "line 1: expected a value"},
+	{"two values on a line", "a = 1 b = 2\n", errTOMLExpectedNewline, "line 1: expected a new line after value"},
+	{"invalid value", "\na = yes\n", errTOMLInvalidValue, "line 2: invalid value: yes"},
+	{"leading zero", "a = 01\n", errTOMLInvalidValue, "line 1: invalid value: 01"},
+	{"integer overflow", "a = 9223372036854775808\n", errTOMLInvalidValue, "line 1: invalid value: 9223372036854775808"},
+	{"unterminated string", "a = \"x\nb = 1\n", errTOMLUnterminated, "line 1: string is not terminated"},
+	{"unterminated multi-line string", "a = '''\nx\n", errTOMLUnterminated, "line 1: string is not terminated"},
+	{"invalid escape", "a = \"\\q\"\n", errInvalidEscape, "line 1: invalid escape sequence: \\q"},
+	{"unclosed array", "a = [\n1,\n2\n", errTOMLArray, "line 4: invalid array"},
+	{"unclosed inline table", "a = { b = 1\n", errTOMLInlineTable, "line 1: invalid inline table"},
+	{"unclosed table header", "[a\n", errTOMLTableHeader, "line 1: invalid table header"},
+	{"duplicate key", "a = 1\na = 2\n", errTOMLDuplicateKey, "line 2: duplicate key: a"},
+	{"duplicate key in inline table", "a = { b = 1, b = 2 }\n", errTOMLDuplicateKey, "line 1: duplicate key: b"},
+	{"table redefined", "[a]\nb = 1\n[a]\nc = 2\n", errTOMLTableRedefined, "line 3: table is already defined: a"},
+	{"table defined by dotted keys", "a.b = 1\n[a]\n", errTOMLTableRedefined, "line 2: table is already defined: a"},
+	{"dotted keys extend table", "[a.b]\nc = 1\n[a]\nb.d = 2\n", errTOMLTableRedefined, "line 4: table is already defined: b"},
+	{"table over value", "a = 1\n[a.b]\n", errTOMLNotTable, "line 2: key is not a table: a"},
+	{"extend inline table", "a = {b = 1}\na.c = 2\n", errTOMLNotTable, "line 2: key is not a table: a"},
+	{"array of tables over array", "a = [1]\n[[a]]\n", errTOMLNotTable, "line 2: key is not a table: a"},
+	{"array of tables over table", "[a]\n[[a]]\n", errTOMLTableRedefined, "line 2: table is already defined: a"},
+	{"duplicate flattened key", "db_host = \"b\"\n[db]\nhost = \"a\"\n", errDuplicateFlatKey, "more than one value has the key: DB_HOST"},
+}
+
+func Test_parseTOML_Errors(t *testing.T) {
+	for _, tt := range test_parseTOML_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.input, nil)
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.expectedMsg, err.Error())
+				assert.True(t, errors.Is(err, tt.expectedErr))
+			}
+		})
+	}
+}
+
+func Test_Parse_TOMLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(path, []byte("tags = [\"a\", \"b\"]\n\n[db]\nhost = \"toml-host\"\nport = 5432\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := struct {
+		Host string   `phnenv:"DB_HOST"`
+		Port int      `phnenv:"DB_PORT"`
+		Tags []string `phnenv:"TAGS"`
+	}{}
+
+	err = Parse(&s, WithSources(Map("env", map[string]string{"DB_HOST": "env-host"}), TOMLFile(path)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "env-host", s.Host)
+		assert.Equal(t, 5432, s.Port)
+		assert.Equal(t, []string{"a", "b"}, s.Tags)
+	}
+}
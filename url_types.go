@@ -0,0 +1,119 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redacted replaces secret values, such as the userinfo of URLs and fields with the secret option, in errors and reports.
+const redacted = "xxxxx"
+
+var (
+	errURLNotAbsolute = errors.New("URL must be absolute")
+	errURLScheme      = errors.New("URL scheme is not allowed")
+)
+
+func init() {
+	typeSetters[reflect.TypeOf(url.URL{})] = setURL
+}
+
+func setURL(conf string, to tagOpts, fieldVal reflect.Value) error {
+	u, err := strToURL(conf, to)
+	if err != nil {
+		return err
+	}
+
+	fieldVal.Set(reflect.ValueOf(*u))
+
+	return nil
+}
+
+// strToURL parses a URL and checks it against the absolute and schemes: tag options.
+// Any userinfo contained in the URL is redacted from the returned errors.
+func strToURL(s string, to tagOpts) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, &url.Error{Op: urlErr.Op, URL: redactURLString(s), Err: urlErr.Err}
+		}
+
+		return nil, err
+	}
+
+	if (to.AbsURL || len(to.URLSchemes) > 0) && !u.IsAbs() {
+		return nil, fmt.Errorf("%w: %q", errURLNotAbsolute, redactURL(u))
+	}
+
+	if len(to.URLSchemes) > 0 && !containsStr(to.URLSchemes, u.Scheme) {
+		return nil, fmt.Errorf("%w: %q must be one of %s", errURLScheme, redactURL(u), strings.Join(to.URLSchemes, ", "))
+	}
+
+	return u, nil
+}
+
+// redactURLString replaces the userinfo in a URL string, which holds the user name and password, with "xxxxx".
+// Unlike url.URL.Redacted, the user name is also replaced, and strings which could not be parsed by url.Parse are handled.
+func redactURLString(s string) string {
+	schemeEnd := strings.Index(s, "://")
+	if schemeEnd < 0 {
+		return s
+	}
+
+	authStart := schemeEnd + len("://")
+	authEnd := len(s)
+	if i := strings.IndexAny(s[authStart:], "/?#"); i >= 0 {
+		authEnd = authStart + i
+	}
+
+	at := strings.LastIndex(s[authStart:authEnd], "@")
+	if at < 0 {
+		return s
+	}
+
+	return s[:authStart] + redacted + s[authStart+at:]
+}
+
+// redactURL returns u as a string with its userinfo replaced in the same way as by redactURLString.
+func redactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+
+	ru := *u
+	ru.User = url.User(redacted)
+
+	return ru.String()
+}
+
+// isURLType reports whether t is url.URL, or a pointer to or slice of url.URL.
+func isURLType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	return t == reflect.TypeOf(url.URL{})
+}
+
+// redactURLList redacts the userinfo of each URL in s, which is a list of URLs separated by sep for slice fields.
+func redactURLList(s string, sep string) string {
+	urls := strings.Split(s, sep)
+	for i, u := range urls {
+		urls[i] = redactURLString(u)
+	}
+
+	return strings.Join(urls, sep)
+}
+
+func containsStr(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,153 @@
+package phnenv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerVaultToken     = "X-Vault-Token"
+	headerVaultNamespace = "X-Vault-Namespace"
+)
+
+var errVaultNoData = errors.New("response has no secret data")
+
+// vaultResponse is the part of a response from Vault's KV v2 API which holds the fields of a secret.
+type vaultResponse struct {
+	Data struct {
+		Data json.RawMessage `json:"data"`
+	} `json:"data"`
+}
+
+// vaultErrorResponse is the body of a response from Vault's API with an error status.
+type vaultErrorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// VaultOption configures the Source returned by Vault. Every HTTPOption is also a VaultOption, so options
+// such as WithToken can be passed to Vault along with WithVaultNamespace and WithVaultVersion.
+type VaultOption interface {
+	applyVault(o *vaultOptions)
+}
+
+type vaultOptions struct {
+	http      httpOptions
+	namespace string
+	version   int
+}
+
+func newVaultOptions(opts []VaultOption) vaultOptions {
+	o := vaultOptions{http: newHTTPOptions(nil)}
+	for _, opt := range opts {
+		opt.applyVault(&o)
+	}
+
+	return o
+}
+
+func (opt HTTPOption) applyVault(o *vaultOptions) {
+	opt(&o.http)
+}
+
+// vaultOption is a VaultOption which only applies to the Vault source.
+type vaultOption func(*vaultOptions)
+
+func (opt vaultOption) applyVault(o *vaultOptions) {
+	opt(o)
+}
+
+// WithVaultNamespace sets the Vault Enterprise namespace of the secret, which is sent in the X-Vault-Namespace header.
+func WithVaultNamespace(namespace string) VaultOption {
+	return vaultOption(func(o *vaultOptions) {
+		o.namespace = namespace
+	})
+}
+
+// WithVaultVersion pins the version of the secret which is read. By default, the latest version is read.
+func WithVaultVersion(version int) VaultOption {
+	return vaultOption(func(o *vaultOptions) {
+		o.version = version
+	})
+}
+
+// Vault returns a Source containing the fields of the secret at path in the KV version 2 secrets engine
+// mounted at mount in the Vault server at addr, such as "https://127.0.0.1:8200". All the fields are read with
+// a single request every time the source is loaded, using Vault's HTTP API.
+//
+// The fields are flattened in the same way as for JSONFile, so the field db_password has the key DB_PASSWORD.
+// This can be changed with WithResponseKeys. It is an error if the secret does not exist, or its version was deleted.
+//
+// The Vault token is set with WithToken, and is sent in the X-Vault-Token header. The namespace and version of
+// the secret can be set with WithVaultNamespace and WithVaultVersion. Requests can also be configured with
+// WithContext, WithHTTPClient, WithTimeout, WithRetry and WithMaxResponseSize.
+func Vault(addr string, mount string, path string, opts ...VaultOption) Source {
+	return vaultSource{url: vaultURL(addr, mount, path), opts: newVaultOptions(opts)}
+}
+
+type vaultSource struct {
+	url  string
+	opts vaultOptions
+}
+
+func (vs vaultSource) Name() string {
+	return redactURLString(vs.url)
+}
+
+func (vs vaultSource) Load() (map[string]string, error) {
+	header := http.Header{}
+	if vs.opts.http.token != "" {
+		header.Set(headerVaultToken, vs.opts.http.token)
+	}
+	if vs.opts.namespace != "" {
+		header.Set(headerVaultNamespace, vs.opts.namespace)
+	}
+
+	u := vs.url
+	if vs.opts.version != 0 {
+		u += "?" + url.Values{"version": {strconv.Itoa(vs.opts.version)}}.Encode()
+	}
+
+	resp, err := doRequest(vs.opts.http, u, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status != http.StatusOK {
+		return nil, vaultError(resp)
+	}
+
+	var vr vaultResponse
+	err = json.Unmarshal(resp.body, &vr)
+	if err != nil {
+		return nil, err
+	}
+	if len(vr.Data.Data) < 1 || string(vr.Data.Data) == "null" {
+		return nil, errVaultNoData
+	}
+
+	return parseJSON(vr.Data.Data, vs.opts.http.flatten)
+}
+
+// vaultURL returns the URL used to read the secret at path in the KV version 2 secrets engine mounted at mount.
+func vaultURL(addr string, mount string, path string) string {
+	parts := strings.Split(strings.Trim(mount, "/")+"/data/"+strings.Trim(path, "/"), "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+
+	return strings.TrimRight(addr, "/") + "/v1/" + strings.Join(parts, "/")
+}
+
+// vaultError returns the error for a response with an error status, including the messages Vault gave for it.
+func vaultError(resp *httpResponse) error {
+	var ver vaultErrorResponse
+	if json.Unmarshal(resp.body, &ver) != nil || len(ver.Errors) < 1 {
+		return statusError(resp)
+	}
+
+	return fmt.Errorf("%w: %s", statusError(resp), strings.Join(ver.Errors, "; "))
+}
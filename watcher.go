@@ -0,0 +1,346 @@
+package phnenv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Watcher holds a config struct which is reloaded while the program runs,
+// for example when the process receives SIGHUP or when a .env file is edited.
+//
+// Every reload parses into a fresh copy of the struct, which replaces the current one only if parsing succeeds.
+// If a reload fails, the previous config is kept and the error is passed to the functions registered with OnError.
+//
+// It is safe to call the methods of a Watcher from several goroutines.
+type Watcher struct {
+	template reflect.Value
+	plan     *structPlan
+	opts     []Option
+
+	mu         sync.RWMutex
+	current    interface{}
+	provenance []Provenance
+	onChange   []func(changes []Change)
+	onError    []func(err error)
+
+	// reloadMu ensures that only one reload runs at a time, so callbacks are called in order.
+	reloadMu sync.Mutex
+}
+
+// Change describes a field whose value was changed by a reload.
+type Change struct {
+	// Field is the path of the field in the struct, such as "DB.Host" for the Host field of a nested struct DB.
+	Field string
+
+	// Key is the config key read by the field.
+	Key string
+
+	// Old and New are the values of the field before and after the reload.
+	// They are both "xxxxx" if the field has the secret tag option.
+	Old interface{}
+	New interface{}
+}
+
+// Trigger decides when a Watcher reloads its config.
+// A Trigger calls reload every time the config should be reloaded, and returns when ctx is done.
+type Trigger func(ctx context.Context, reload func())
+
+// NewWatcher parses config into a copy of the struct pointed to by v, and returns a Watcher holding the result.
+// The options are used for the first parse and every reload.
+//
+// If WithProvenance is given, its report is only filled by the first parse, because reloads run in the background
+// while the caller may be reading it. The Provenance of the current config is returned by the Watcher's
+// Provenance method instead.
+//
+// v is not modified. Its field values are copied before every parse, so fields which are not set by
+// the config keep the values they had in v. This can be used to provide defaults.
+//
+// NewWatcher returns an error in the same cases as Parse.
+func NewWatcher(v interface{}, opts ...Option) (*Watcher, error) {
+	w, err := newWatcher(v, opts)
+	if err != nil {
+		return nil, fmt.Errorf(errWrapFmt, err)
+	}
+
+	return w, nil
+}
+
+func newWatcher(v interface{}, opts []Option) (*Watcher, error) {
+	sv, err := validateInput(v)
+	if err != nil {
+		return nil, err
+	}
+
+	sp, err := planFor(sv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{template: cloneStruct(sp, sv).Elem(), plan: sp, opts: opts}
+
+	w.current, w.provenance, err = w.parseCopy()
+	if err != nil {
+		return nil, err
+	}
+
+	if report := newOptions(opts).provenance; report != nil {
+		*report = append([]Provenance{}, w.provenance...)
+	}
+
+	return w, nil
+}
+
+// Get returns a pointer to the current config struct, which has the same type as the v passed to NewWatcher.
+// The struct must not be modified. A reload replaces it with a new struct instead of changing it,
+// so a caller which keeps the pointer sees a consistent config.
+func (w *Watcher) Get() interface{} {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.current
+}
+
+// Provenance returns the Provenance of every field of the current config, if NewWatcher was called with
+// WithProvenance. It is nil otherwise. Like the struct returned by Get, the slice must not be modified.
+func (w *Watcher) Provenance() []Provenance {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.provenance
+}
+
+// OnChange registers a function which is called after a reload which changed the value of at least one field.
+// Functions are called in the order they were registered, one reload at a time.
+func (w *Watcher) OnChange(f func(changes []Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onChange = append(w.onChange, f)
+}
+
+// OnError registers a function which is called when a reload fails.
+func (w *Watcher) OnError(f func(err error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onError = append(w.onError, f)
+}
+
+// Reload parses the config again. If parsing succeeds, the new config replaces the current one and the
+// OnChange functions are called with the fields which changed. If parsing fails, the current config is kept,
+// the OnError functions are called, and the error is returned.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	next, provenance, err := w.parseCopy()
+	if err != nil {
+		err = fmt.Errorf(errWrapFmt, err)
+
+		w.mu.RLock()
+		onError := w.onError
+		w.mu.RUnlock()
+
+		for _, f := range onError {
+			f(err)
+		}
+
+		return err
+	}
+
+	w.mu.Lock()
+	prev := w.current
+	w.current, w.provenance = next, provenance
+	onChange := w.onChange
+	w.mu.Unlock()
+
+	changes := diffStructs(w.plan, "", reflect.ValueOf(prev).Elem(), reflect.ValueOf(next).Elem(), nil)
+	if len(changes) < 1 {
+		return nil
+	}
+
+	for _, f := range onChange {
+		f(changes)
+	}
+
+	return nil
+}
+
+// Run calls Reload whenever one of the triggers fires, until ctx is done.
+// Reload errors are passed to the OnError functions.
+func (w *Watcher) Run(ctx context.Context, triggers ...Trigger) {
+	var wg sync.WaitGroup
+
+	for _, t := range triggers {
+		wg.Add(1)
+		go func(t Trigger) {
+			defer wg.Done()
+			t(ctx, func() { _ = w.Reload() })
+		}(t)
+	}
+
+	wg.Wait()
+}
+
+// parseCopy parses config into a new copy of the template. If provenance was requested, it is recorded in
+// a new slice rather than the caller's report, which could be read by the caller while a reload runs.
+func (w *Watcher) parseCopy() (interface{}, []Provenance, error) {
+	next := cloneStruct(w.plan, w.template)
+
+	o := newOptions(w.opts)
+	var provenance []Provenance
+	if o.provenance != nil {
+		o.provenance = &provenance
+	}
+
+	err := parseSources(next.Interface(), o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return next.Interface(), provenance, nil
+}
+
+// OnSignal returns a Trigger which reloads the config whenever the process receives one of sigs, such as syscall.SIGHUP.
+// Unlike signal.Notify, OnSignal does nothing if no signals are given.
+func OnSignal(sigs ...os.Signal) Trigger {
+	return func(ctx context.Context, reload func()) {
+		if len(sigs) < 1 {
+			<-ctx.Done()
+			return
+		}
+
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, sigs...)
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				reload()
+			}
+		}
+	}
+}
+
+// OnFileChange returns a Trigger which checks the files at paths every interval, and reloads the config
+// when any of them is created, deleted, or has a new size or modification time.
+// Symbolic links are followed, so this also detects updates of Kubernetes secrets mounted as files.
+func OnFileChange(interval time.Duration, paths ...string) Trigger {
+	return func(ctx context.Context, reload func()) {
+		states := statFiles(paths)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				next := statFiles(paths)
+				if !reflect.DeepEqual(states, next) {
+					states = next
+					reload()
+				}
+			}
+		}
+	}
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFiles(paths []string) []fileState {
+	res := make([]fileState, len(paths))
+
+	for i, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+
+		res[i] = fileState{exists: true, size: fi.Size(), modTime: fi.ModTime()}
+	}
+
+	return res
+}
+
+// cloneStruct returns a pointer to a copy of the struct sv. Pointers to nested structs are copied too, because
+// parsing writes through them. Other pointers are shared, because parsing replaces them instead of writing through them.
+func cloneStruct(sp *structPlan, sv reflect.Value) reflect.Value {
+	res := reflect.New(sv.Type())
+	res.Elem().Set(sv)
+	cloneNested(sp, res.Elem())
+
+	return res
+}
+
+func cloneNested(sp *structPlan, sv reflect.Value) {
+	for _, fp := range sp.fields {
+		if fp.nested == nil {
+			continue
+		}
+
+		fv := sv.Field(fp.index)
+		for fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.CanSet() {
+			p := reflect.New(fv.Type().Elem())
+			p.Elem().Set(fv.Elem())
+			fv.Set(p)
+			fv = fv.Elem()
+		}
+
+		if fv.Kind() == reflect.Struct {
+			cloneNested(fp.nested, fv)
+		}
+	}
+}
+
+// diffStructs appends a Change to res for every field which differs between the structs prev and next.
+func diffStructs(sp *structPlan, prefix string, prev reflect.Value, next reflect.Value, res []Change) []Change {
+	for _, fp := range sp.fields {
+		name := prefix + fp.name
+		pf, nf := prev.Field(fp.index), next.Field(fp.index)
+
+		if fp.nested != nil {
+			res = diffStructs(fp.nested, name+".", derefOrZero(pf), derefOrZero(nf), res)
+			continue
+		}
+
+		if !pf.CanInterface() || reflect.DeepEqual(pf.Interface(), nf.Interface()) {
+			continue
+		}
+
+		c := Change{Field: name, Key: fp.key, Old: pf.Interface(), New: nf.Interface()}
+		if fp.opts.Secret {
+			c.Old, c.New = redacted, redacted
+		}
+
+		res = append(res, c)
+	}
+
+	return res
+}
+
+// derefOrZero follows pointers to a struct, returning the zero struct if a pointer is nil.
+func derefOrZero(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Zero(derefType(v.Type()))
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
@@ -0,0 +1,55 @@
+//go:build !windows && !plan9
+
+package phnenv
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_Watcher_Run_OnSignal(t *testing.T) {
+	src := &changingSource{values: map[string]string{"LOG_LEVEL": "info"}}
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(src))
+	if !assert.Nil(t, err) {
+		return
+	}
+	changed := make(chan []Change, 1)
+	w.OnChange(func(c []Change) { changed <- c })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx, OnSignal(syscall.SIGHUP))
+		close(done)
+	}()
+
+	// stops SIGHUP from terminating the test process before the trigger has started listening
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	src.set(map[string]string{"LOG_LEVEL": "debug"}, nil)
+	deadline := time.After(5 * time.Second)
+	for {
+		// the trigger may not be listening yet, so keep sending the signal until the reload happens
+		assert.Nil(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+
+		select {
+		case c := <-changed:
+			assert.Equal(t, "debug", c[0].New)
+		case <-time.After(10 * time.Millisecond):
+			continue
+		case <-deadline:
+			t.Error("timed out waiting for reload")
+		}
+
+		break
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,245 @@
+package phnenv
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// changingSource is a Source whose values can be changed between loads.
+type changingSource struct {
+	mu     sync.Mutex
+	values map[string]string
+	err    error
+}
+
+func (cs *changingSource) Name() string {
+	return "changing"
+}
+
+func (cs *changingSource) Load() (map[string]string, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.values, cs.err
+}
+
+func (cs *changingSource) set(values map[string]string, err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.values, cs.err = values, err
+}
+
+type watcherTestDB struct {
+	Host string `phnenv:"DB_HOST"`
+	Port int    `phnenv:"DB_PORT"`
+}
+
+type watcherTestConf struct {
+	LogLevel string   `phnenv:"LOG_LEVEL"`
+	Tags     []string `phnenv:"TAGS"`
+	DB       *watcherTestDB
+}
+
+func Test_NewWatcher_ParsesIntoCopy(t *testing.T) {
+	src := &changingSource{values: map[string]string{"LOG_LEVEL": "info", "DB_HOST": "db"}}
+	defaults := watcherTestConf{LogLevel: "warn", DB: &watcherTestDB{Port: 5432}}
+
+	w, err := NewWatcher(&defaults, WithSources(src))
+
+	if assert.Nil(t, err) {
+		conf := w.Get().(*watcherTestConf)
+		assert.Equal(t, "info", conf.LogLevel)
+		assert.Equal(t, &watcherTestDB{Host: "db", Port: 5432}, conf.DB)
+		assert.Equal(t, watcherTestConf{LogLevel: "warn", DB: &watcherTestDB{Port: 5432}}, defaults)
+	}
+}
+
+func Test_NewWatcher_Error(t *testing.T) {
+	src := &changingSource{values: map[string]string{"DB_PORT": "abc"}}
+
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(src))
+
+	assert.Nil(t, w)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `phnenv: field "DB": field "Port"`)
+	}
+}
+
+func Test_Watcher_Reload_CallsOnChangeWithDiff(t *testing.T) {
+	src := &changingSource{values: map[string]string{"LOG_LEVEL": "info", "TAGS": "a,b", "DB_PORT": "1"}}
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(src))
+	if !assert.Nil(t, err) {
+		return
+	}
+	first := w.Get().(*watcherTestConf)
+	var changes []Change
+	w.OnChange(func(c []Change) { changes = c })
+
+	src.set(map[string]string{"LOG_LEVEL": "debug", "TAGS": "a,b", "DB_HOST": "db", "DB_PORT": "1"}, nil)
+	err = w.Reload()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []Change{
+			{Field: "LogLevel", Key: "LOG_LEVEL", Old: "info", New: "debug"},
+			{Field: "DB.Host", Key: "DB_HOST", Old: "", New: "db"},
+		}, changes)
+		assert.Equal(t, "debug", w.Get().(*watcherTestConf).LogLevel)
+		assert.Equal(t, "info", first.LogLevel)
+		assert.Equal(t, "", first.DB.Host)
+	}
+}
+
+func Test_Watcher_Reload_WithProvenance_RecordedOnWatcher(t *testing.T) {
+	src := &changingSource{values: map[string]string{"LOG_LEVEL": "info"}}
+	var report []Provenance
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(src), WithProvenance(&report))
+	if !assert.Nil(t, err) {
+		return
+	}
+	first := w.Provenance()
+
+	src.set(map[string]string{"LOG_LEVEL": "debug"}, nil)
+	err = w.Reload()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, Provenance{Field: "LogLevel", Key: "LOG_LEVEL", Source: "changing", Value: "info"}, report[0])
+		assert.Equal(t, report, first)
+		assert.Equal(t, Provenance{Field: "LogLevel", Key: "LOG_LEVEL", Source: "changing", Value: "debug"}, w.Provenance()[0])
+	}
+}
+
+func Test_Watcher_WithoutProvenance_ProvenanceNil(t *testing.T) {
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(&changingSource{}))
+
+	if assert.Nil(t, err) {
+		assert.Nil(t, w.Provenance())
+	}
+}
+
+func Test_Watcher_Reload_RedactsSecrets(t *testing.T) {
+	src := &changingSource{values: map[string]string{"PASSWORD": "old"}}
+	w, err := NewWatcher(&struct {
+		Password string `phnenv:"PASSWORD,secret"`
+	}{}, WithSources(src))
+	if !assert.Nil(t, err) {
+		return
+	}
+	var changes []Change
+	w.OnChange(func(c []Change) { changes = c })
+
+	src.set(map[string]string{"PASSWORD": "new"}, nil)
+	err = w.Reload()
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, []Change{{Field: "Password", Key: "PASSWORD", Old: "xxxxx", New: "xxxxx"}}, changes)
+	}
+}
+
+func Test_Watcher_Reload_NoChanges_OnChangeNotCalled(t *testing.T) {
+	src := &changingSource{values: map[string]string{"LOG_LEVEL": "info"}}
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(src))
+	if !assert.Nil(t, err) {
+		return
+	}
+	called := false
+	w.OnChange(func([]Change) { called = true })
+
+	err = w.Reload()
+
+	assert.Nil(t, err)
+	assert.False(t, called)
+}
+
+func Test_Watcher_Reload_Error_KeepsPreviousConfig(t *testing.T) {
+	src := &changingSource{values: map[string]string{"LOG_LEVEL": "info"}}
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(src))
+	if !assert.Nil(t, err) {
+		return
+	}
+	var reported error
+	w.OnError(func(err error) { reported = err })
+	w.OnChange(func([]Change) { t.Error("OnChange should not be called") })
+
+	src.set(map[string]string{"LOG_LEVEL": "debug"}, errors.New("it broke"))
+	err = w.Reload()
+
+	if assert.NotNil(t, err) {
+		assert.Equal(t, `phnenv: source "changing": it broke`, err.Error())
+		assert.Equal(t, err, reported)
+		assert.Equal(t, "info", w.Get().(*watcherTestConf).LogLevel)
+	}
+}
+
+func Test_Watcher_Run_OnFileChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if !assert.Nil(t, os.WriteFile(path, []byte("LOG_LEVEL=info\n"), 0600)) {
+		return
+	}
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(DotEnv(path)))
+	if !assert.Nil(t, err) {
+		return
+	}
+	changed := make(chan []Change, 1)
+	w.OnChange(func(c []Change) { changed <- c })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx, OnFileChange(5*time.Millisecond, path))
+		close(done)
+	}()
+
+	err = os.WriteFile(path, []byte("LOG_LEVEL=debug\n"), 0600)
+	assert.Nil(t, err)
+	deadline := time.After(5 * time.Second)
+	for i := 1; ; i++ {
+		// the trigger may not have checked the file yet, so keep changing the modification time until the reload happens
+		assert.Nil(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Duration(i)*time.Hour)))
+
+		select {
+		case c := <-changed:
+			assert.Equal(t, []Change{{Field: "LogLevel", Key: "LOG_LEVEL", Old: "info", New: "debug"}}, c)
+		case <-time.After(10 * time.Millisecond):
+			continue
+		case <-deadline:
+			t.Error("timed out waiting for reload")
+		}
+
+		break
+	}
+
+	cancel()
+	<-done
+}
+
+func Test_Watcher_ConcurrentGetAndReload(t *testing.T) {
+	src := &changingSource{values: map[string]string{"LOG_LEVEL": "info"}}
+	w, err := NewWatcher(&watcherTestConf{}, WithSources(src))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = w.Reload()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, "info", w.Get().(*watcherTestConf).LogLevel)
+			}
+		}()
+	}
+	wg.Wait()
+}
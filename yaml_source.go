@@ -0,0 +1,642 @@
+package phnenv
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+const (
+	yamlDocumentStart = "---"
+	yamlDocumentEnd   = "..."
+)
+
+var (
+	errYAMLNotMapping     = errors.New("YAML document must be a mapping")
+	errYAMLIndent         = errors.New("unexpected indentation")
+	errYAMLTab            = errors.New("tabs must not be used for indentation")
+	errYAMLExpectedEntry  = errors.New("expected key: value")
+	errYAMLDuplicateKey   = errors.New("duplicate key")
+	errYAMLUnsupported    = errors.New("anchors, aliases, tags and directives are not supported")
+	errYAMLMultipleDocs   = errors.New("multiple documents are not supported")
+	errYAMLUnterminated   = errors.New("quoted string is not terminated")
+	errYAMLTrailing       = errors.New("unexpected characters after quoted string")
+	errYAMLFlow           = errors.New("invalid flow collection")
+	errYAMLBlockScalar    = errors.New("invalid block scalar header")
+	errYAMLBlockScalarEnd = errors.New("block scalar content must be indented")
+)
+
+// YAMLFile returns a Source containing the values in the YAML file at path.
+// The file is read every time the source is loaded. Keys are flattened in the same way as for JSONFile,
+// so the YAML
+//
+//	db:
+//	  host: localhost
+//
+// has the key DB_HOST.
+//
+// Only a practical subset of YAML is supported: block mappings and sequences, flow sequences and mappings
+// on a single line (such as [a, b]), plain, single quoted and double quoted scalars, literal (|) and
+// folded (>) block scalars, and comments. Scalars are read as text without being converted to other types,
+// except for null and ~ which are treated as not set. Anchors, aliases, tags and multiple documents are not supported.
+func YAMLFile(path string, opts ...FlattenOption) Source {
+	return yamlSource{path: path, opts: opts}
+}
+
+// YAMLFileFS returns a Source like YAMLFile which reads the YAML file at path in fsys, such as an embed.FS.
+func YAMLFileFS(fsys fs.FS, path string, opts ...FlattenOption) Source {
+	return yamlSource{fsys: fsys, path: path, opts: opts}
+}
+
+type yamlSource struct {
+	fsys fs.FS
+	path string
+	opts []FlattenOption
+}
+
+func (ys yamlSource) Name() string {
+	return ys.path
+}
+
+func (ys yamlSource) Load() (map[string]string, error) {
+	b, err := readFile(ys.fsys, ys.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseYAML(string(b), ys.opts)
+}
+
+func parseYAML(s string, opts []FlattenOption) (map[string]string, error) {
+	doc, err := newYAMLParser(s).parseDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	f := newFlattener(opts)
+
+	err = f.flatten("", doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.res, nil
+}
+
+// yamlLine is a line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+
+	// text is the content of the line without indentation, comments or trailing whitespace.
+	// It is "" if the line is blank or only holds a comment.
+	text string
+
+	// raw is the whole line, which is used for the content of block scalars.
+	raw string
+}
+
+// yamlParser parses the lines of a YAML document, from the line at index pos onwards.
+type yamlParser struct {
+	lines []*yamlLine
+	pos   int
+}
+
+func newYAMLParser(s string) *yamlParser {
+	var lines []*yamlLine
+
+	for i, raw := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		content := strings.TrimLeft(raw, " ")
+
+		lines = append(lines, &yamlLine{
+			num:    i + 1,
+			indent: len(raw) - len(content),
+			text:   strings.TrimRight(stripYAMLComment(content), " \t"),
+			raw:    raw,
+		})
+	}
+
+	return &yamlParser{lines: lines}
+}
+
+// peek returns the next line which is not blank, or nil at the end of the document.
+func (p *yamlParser) peek() *yamlLine {
+	for p.pos < len(p.lines) && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+
+	if p.pos >= len(p.lines) {
+		return nil
+	}
+
+	return p.lines[p.pos]
+}
+
+func (p *yamlParser) parseDocument() (map[string]interface{}, error) {
+	l := p.peek()
+	if l != nil && strings.HasPrefix(l.text, "%") {
+		return nil, yamlError(l, errYAMLUnsupported)
+	}
+	if l != nil && l.indent == 0 && l.text == yamlDocumentStart {
+		p.pos++
+		l = p.peek()
+	}
+
+	if l == nil || l.indent == 0 && l.text == yamlDocumentEnd {
+		return map[string]interface{}{}, nil
+	}
+
+	doc, err := p.parseBlock(l)
+	if err != nil {
+		return nil, err
+	}
+
+	if l := p.peek(); l != nil {
+		switch {
+		case l.indent == 0 && l.text == yamlDocumentStart:
+			return nil, yamlError(l, errYAMLMultipleDocs)
+		case l.indent == 0 && l.text == yamlDocumentEnd:
+		default:
+			return nil, yamlError(l, errYAMLIndent)
+		}
+	}
+
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errYAMLNotMapping
+	}
+
+	return m, nil
+}
+
+// parseBlock parses the mapping, sequence or scalar which starts at the line l, and has the indentation of l.
+func (p *yamlParser) parseBlock(l *yamlLine) (interface{}, error) {
+	if strings.HasPrefix(l.text, "\t") {
+		return nil, yamlError(l, errYAMLTab)
+	}
+
+	if isYAMLSeqItem(l.text) {
+		return p.parseSeq(l.indent)
+	}
+
+	_, _, ok, err := splitYAMLEntry(l.text)
+	if err != nil {
+		return nil, yamlError(l, err)
+	}
+	if ok {
+		return p.parseMap(l.indent)
+	}
+
+	p.pos++
+
+	return p.parseValue(l, l.text, l.indent-1, false)
+}
+
+func (p *yamlParser) parseMap(indent int) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+
+	for {
+		l := p.peek()
+		if l == nil || l.indent < indent || l.indent == 0 && (l.text == yamlDocumentStart || l.text == yamlDocumentEnd) {
+			return res, nil
+		}
+		if l.indent > indent {
+			return nil, yamlError(l, errYAMLIndent)
+		}
+		if strings.HasPrefix(l.text, "\t") {
+			return nil, yamlError(l, errYAMLTab)
+		}
+
+		key, rest, ok, err := splitYAMLEntry(l.text)
+		if err != nil {
+			return nil, yamlError(l, err)
+		}
+		if !ok {
+			return nil, yamlError(l, errYAMLExpectedEntry)
+		}
+		if _, ok := res[key]; ok {
+			return nil, yamlError(l, fmt.Errorf("%w: %s", errYAMLDuplicateKey, key))
+		}
+
+		p.pos++
+
+		res[key], err = p.parseValue(l, rest, indent, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *yamlParser) parseSeq(indent int) ([]interface{}, error) {
+	res := []interface{}{}
+
+	for {
+		l := p.peek()
+		if l == nil || l.indent < indent || l.indent == indent && !isYAMLSeqItem(l.text) {
+			return res, nil
+		}
+		if l.indent > indent {
+			return nil, yamlError(l, errYAMLIndent)
+		}
+
+		rest := strings.TrimLeft(l.text[1:], " ")
+
+		_, _, isEntry, err := splitYAMLEntry(rest)
+		if err != nil {
+			return nil, yamlError(l, err)
+		}
+
+		if isEntry || isYAMLSeqItem(rest) {
+			// the item is a nested collection starting on the same line as the "-",
+			// so treat the rest of the line as if it were on its own line with the same indentation
+			l.indent += len(l.text) - len(rest)
+			l.text = rest
+
+			v, err := p.parseBlock(l)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, v)
+			continue
+		}
+
+		p.pos++
+
+		v, err := p.parseValue(l, rest, indent, false)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, v)
+	}
+}
+
+// parseValue parses the value which follows a key or "-" on the line l, whose parent has the given indentation.
+// If the value is empty, it is read from the following, more indented lines. If inMap is true, it may also be
+// a sequence with the same indentation as its parent's key.
+func (p *yamlParser) parseValue(l *yamlLine, rest string, indent int, inMap bool) (interface{}, error) {
+	if rest == "" {
+		next := p.peek()
+		switch {
+		case next != nil && next.indent > indent:
+			return p.parseBlock(next)
+		case inMap && next != nil && next.indent == indent && isYAMLSeqItem(next.text):
+			return p.parseSeq(indent)
+		default:
+			return nil, nil
+		}
+	}
+
+	if rest[0] == '|' || rest[0] == '>' {
+		return p.parseBlockScalar(l, rest, indent)
+	}
+
+	v, err := parseYAMLInline(rest)
+	if err != nil {
+		return nil, yamlError(l, err)
+	}
+
+	return v, nil
+}
+
+// parseBlockScalar parses the content of a literal (|) or folded (>) block scalar, whose header is on the line l.
+func (p *yamlParser) parseBlockScalar(l *yamlLine, header string, indent int) (string, error) {
+	chomp := header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return "", yamlError(l, fmt.Errorf("%w: %s", errYAMLBlockScalar, header))
+	}
+
+	var lines []string
+	contentIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		if contentIndent < 0 {
+			if line.indent <= indent {
+				break
+			}
+			contentIndent = line.indent
+		}
+		if line.indent < contentIndent {
+			if line.indent > indent {
+				return "", yamlError(line, errYAMLBlockScalarEnd)
+			}
+			break
+		}
+
+		lines = append(lines, line.raw[contentIndent:])
+	}
+
+	// trailing blank lines are part of the scalar only for the "+" chomping indicator,
+	// so leave them to be skipped as blank lines otherwise
+	trailing := 0
+	for trailing < len(lines) && lines[len(lines)-1-trailing] == "" {
+		trailing++
+	}
+	lines = lines[:len(lines)-trailing]
+
+	var res string
+	if header[0] == '|' {
+		res = strings.Join(lines, "\n")
+	} else {
+		res = foldYAMLLines(lines)
+	}
+
+	switch {
+	case len(lines) < 1 || chomp == "-":
+	case chomp == "+":
+		res += strings.Repeat("\n", trailing+1)
+	default:
+		res += "\n"
+	}
+
+	return res, nil
+}
+
+// foldYAMLLines joins the lines of a folded block scalar. Lines are joined with spaces,
+// and each blank line becomes a line break. More indented lines are kept as they are.
+func foldYAMLLines(lines []string) string {
+	var sb strings.Builder
+
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case line == "" || prev == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(prev, " "):
+				if prev != "" || line == "" {
+					sb.WriteString("\n")
+				}
+			default:
+				sb.WriteString(" ")
+			}
+		}
+
+		sb.WriteString(line)
+	}
+
+	return sb.String()
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLEntry splits a "key: value" mapping entry into its key and the rest of the line.
+// The bool result is false if text is not a mapping entry.
+func splitYAMLEntry(text string) (string, string, bool, error) {
+	if text == "" || strings.ContainsRune("[{&*!|>%@`", rune(text[0])) {
+		return "", "", false, nil
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		key, end, err := parseYAMLQuoted(text)
+		if err != nil {
+			return "", "", false, err
+		}
+
+		rest := strings.TrimLeft(text[end:], " ")
+		if rest == ":" || strings.HasPrefix(rest, ": ") {
+			return key, strings.TrimLeft(rest[1:], " "), true, nil
+		}
+
+		return "", "", false, nil
+	}
+
+	if strings.HasSuffix(text, ":") && !strings.Contains(text, ": ") {
+		return strings.TrimRight(text[:len(text)-1], " "), "", true, nil
+	}
+
+	i := strings.Index(text, ": ")
+	if i < 0 {
+		return "", "", false, nil
+	}
+
+	return strings.TrimRight(text[:i], " "), strings.TrimLeft(text[i+2:], " "), true, nil
+}
+
+// parseYAMLInline parses a scalar or a flow collection which is on a single line. An empty string is null.
+func parseYAMLInline(s string) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	switch s[0] {
+	case '&', '*', '!', '%', '@', '`':
+		return nil, errYAMLUnsupported
+	case '[', '{':
+		v, end, err := parseYAMLFlow(s, 0)
+		if err != nil {
+			return nil, err
+		}
+		if end != len(s) {
+			return nil, errYAMLFlow
+		}
+
+		return v, nil
+	case '"', '\'':
+		v, end, err := parseYAMLQuoted(s)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(s[end:]) != "" {
+			return nil, errYAMLTrailing
+		}
+
+		return v, nil
+	default:
+		return plainYAMLScalar(s), nil
+	}
+}
+
+// plainYAMLScalar returns the value of an unquoted scalar, which is nil for the null values.
+func plainYAMLScalar(s string) interface{} {
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return nil
+	default:
+		return s
+	}
+}
+
+// parseYAMLFlow parses the flow collection starting at s[start], and returns its value and the index after its end.
+func parseYAMLFlow(s string, start int) (interface{}, int, error) {
+	closing := byte(']')
+	var seq []interface{}
+	var m map[string]interface{}
+	if s[start] == '{' {
+		closing = '}'
+		m = map[string]interface{}{}
+	} else {
+		seq = []interface{}{}
+	}
+
+	i := skipSpaces(s, start+1)
+	if i < len(s) && s[i] == closing {
+		return flowResult(seq, m), i + 1, nil
+	}
+
+	for i < len(s) {
+		// a comma may follow the last item, but items may not be empty
+		if s[i] == closing {
+			return flowResult(seq, m), i + 1, nil
+		}
+		if s[i] == ',' {
+			return nil, 0, errYAMLFlow
+		}
+
+		var key string
+		if m != nil {
+			end := strings.IndexAny(s[i:], ":,}")
+			if end < 0 || s[i+end] != ':' || strings.TrimSpace(s[i:i+end]) == "" {
+				return nil, 0, errYAMLFlow
+			}
+
+			k, err := parseYAMLInline(strings.TrimSpace(s[i : i+end]))
+			if err != nil {
+				return nil, 0, err
+			}
+			key, _ = k.(string)
+			if _, ok := m[key]; ok {
+				return nil, 0, fmt.Errorf("%w: %s", errYAMLDuplicateKey, key)
+			}
+
+			i = skipSpaces(s, i+end+1)
+		}
+
+		v, end, err := parseYAMLFlowItem(s, i)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if m != nil {
+			m[key] = v
+		} else {
+			seq = append(seq, v)
+		}
+
+		i = skipSpaces(s, end)
+		if i >= len(s) {
+			break
+		}
+		if s[i] == closing {
+			return flowResult(seq, m), i + 1, nil
+		}
+		if s[i] != ',' {
+			return nil, 0, errYAMLFlow
+		}
+
+		i = skipSpaces(s, i+1)
+	}
+
+	return nil, 0, errYAMLFlow
+}
+
+func flowResult(seq []interface{}, m map[string]interface{}) interface{} {
+	if m != nil {
+		return m
+	}
+
+	return seq
+}
+
+// parseYAMLFlowItem parses a value inside a flow collection starting at s[i], and returns it and the index after it.
+func parseYAMLFlowItem(s string, i int) (interface{}, int, error) {
+	if i >= len(s) {
+		return nil, 0, errYAMLFlow
+	}
+
+	switch s[i] {
+	case '[', '{':
+		return parseYAMLFlow(s, i)
+	case '"', '\'':
+		v, end, err := parseYAMLQuoted(s[i:])
+		return v, i + end, err
+	default:
+		end := strings.IndexAny(s[i:], ",]}")
+		if end < 0 {
+			return nil, 0, errYAMLFlow
+		}
+
+		v, err := parseYAMLInline(strings.TrimSpace(s[i : i+end]))
+
+		return v, i + end, err
+	}
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+
+	return i
+}
+
+// parseYAMLQuoted parses the quoted string at the start of s, and returns its value and the index after its end.
+func parseYAMLQuoted(s string) (string, int, error) {
+	if s[0] == '\'' {
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				sb.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				sb.WriteByte('\'')
+				i++
+				continue
+			}
+
+			return sb.String(), i + 1, nil
+		}
+
+		return "", 0, errYAMLUnterminated
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			v, err := unescapeQuoted(s[1:i])
+			return v, i + 1, err
+		}
+	}
+
+	return "", 0, errYAMLUnterminated
+}
+
+// stripYAMLComment removes a comment from the end of a line. A "#" starts a comment if it is at the start of the
+// line or follows whitespace, and is not inside a quoted string.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			if quote == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:", s[i-1]) >= 0):
+			quote = c
+		case quote == 0 && c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+
+	return s
+}
+
+func yamlError(l *yamlLine, err error) error {
+	return fmt.Errorf(errLineWrapFmt, l.num, err)
+}
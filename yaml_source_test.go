@@ -0,0 +1,125 @@
+package phnenv
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var test_parseYAML = []struct {
+	name     string
+	input    string
+	expected map[string]string
+}{
+	{"empty document", "", map[string]string{}},
+	{"only comments", "# config\n\n# more\n", map[string]string{}},
+	{"document markers", "---\nname: app\n...\n", map[string]string{"NAME": "app"}},
+	{"scalars", "name: app\nport: 8080\nratio: 1.5\ndebug: true\n", map[string]string{"NAME": "app", "PORT": "8080", "RATIO": "1.5", "DEBUG": "true"}},
+	{"null is not set", "a: null\nb: ~\nc:\n", map[string]string{}},
+	{"comments", "# config\nname: app # the name\nurl: http://x/#frag\n", map[string]string{"NAME": "app", "URL": "http://x/#frag"}},
+	{"quoted scalars", "a: \"x # y\"\nb: 'it''s'\nc: \"tab\\there\\u00e9\"\n\"d e\": 'null'\n",
+		map[string]string{"A": "x # y", "B": "it's", "C": "tab\there\u00e9", "D E": "null"}},
+	{"nested maps", "db:\n  host: localhost\n  pool:\n    size: 5\nname: app\n",
+		map[string]string{"DB_HOST": "localhost", "DB_POOL_SIZE": "5", "NAME": "app"}},
+	{"sequence of scalars", "tags:\n  - a\n  - b # comment\n  - 'c'\n", map[string]string{"TAGS": "a,b,c"}},
+	{"sequence at the same indentation as its key", "tags:\n- a\n- b\nname: app\n", map[string]string{"TAGS": "a,b", "NAME": "app"}},
+	{"sequence of maps", "servers:\n  - host: a\n    port: 1\n  - host: b\n    ports: [1, 2]\n",
+		map[string]string{"SERVERS_0_HOST": "a", "SERVERS_0_PORT": "1", "SERVERS_1_HOST": "b", "SERVERS_1_PORTS": "1,2"}},
+	{"nested sequences", "matrix:\n  - - 1\n    - 2\n  - [3, 4]\n", map[string]string{"MATRIX_0": "1,2", "MATRIX_1": "3,4"}},
+	{"flow collections", "tags: [a, 'b, c', \"d\"]\nempty: []\ndb: {host: localhost, port: 5432}\n",
+		map[string]string{"TAGS": "a,b, c,d", "EMPTY": "", "DB_HOST": "localhost", "DB_PORT": "5432"}},
+	{"flow trailing comma", "tags: [x, y,]\ndb: {host: localhost, }\n", map[string]string{"TAGS": "x,y", "DB_HOST": "localhost"}},
+	{"flow empty value is null", "a: {x: }\nb: {x: , y: 1}\n", map[string]string{"B_Y": "1"}},
+	{"literal block scalar", "cert: |\n  line 1\n    line 2\n\n  line 4\nname: app\n",
+		map[string]string{"CERT": "line 1\n  line 2\n\nline 4\n", "NAME": "app"}},
+	{"literal block scalar strip", "cert: |-\n  line 1\n  line 2\n\n", map[string]string{"CERT": "line 1\nline 2"}},
+	{"literal block scalar keep", "cert: |+\n  line 1\n\n", map[string]string{"CERT": "line 1\n\n"}},
+	{"folded block scalar", "desc: >\n  a long\n  sentence\n\n  next\n", map[string]string{"DESC": "a long sentence\nnext\n"}},
+	{"windows line endings", "db:\r\n  host: localhost\r\n", map[string]string{"DB_HOST": "localhost"}},
+	{"colon in value", "url: http://localhost:8080\ntime: 12:30\n", map[string]string{"URL": "http://localhost:8080", "TIME": "12:30"}},
+}
+
+func Test_parseYAML(t *testing.T) {
+	for _, tt := range test_parseYAML {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseYAML(tt.input, nil)
+
+			if assert.Nil(t, err) {
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func Test_parseYAML_FlattenOptions(t *testing.T) {
+	res, err := parseYAML("db:\n  Host: localhost\n", []FlattenOption{WithKeyCase(PreserveCase), WithKeySeparator(".")})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"db.Host": "localhost"}, res)
+	}
+}
+
+var test_parseYAML_Errors = []struct {
+	name        string
+	input       string
+	expectedErr error
+	expectedMsg string
+}{
+	{"not a mapping", "- a\n- b\n", errYAMLNotMapping, "YAML document must be a mapping"},
+	{"scalar document", "hello\n", errYAMLNotMapping, "YAML document must be a mapping"},
+	{"unexpected indentation", "a: 1\n  b: 2\n", errYAMLIndent, "line 2: unexpected indentation"},
+	{"tab indentation", "a:\n\tb: 2\n", errYAMLTab, "line 2: tabs must not be used for indentation"},
+	{"missing colon", "a: 1\nb\n", errYAMLExpectedEntry, "line 2: expected key: value"},
+	{"duplicate key", "a: 1\n\na: 2\n", errYAMLDuplicateKey, "line 3: duplicate key: a"},
+	{"anchor", "a: &x 1\n", errYAMLUnsupported, "line 1: anchors, aliases, tags and directives are not supported"},
+	{"alias", "a: 1\nb: *x\n", errYAMLUnsupported, "line 2: anchors, aliases, tags and directives are not supported"},
+	{"tag", "a: !!str 1\n", errYAMLUnsupported, "line 1: anchors, aliases, tags and directives are not supported"},
+	{"directive", "%YAML 1.2\n---\na: 1\n", errYAMLUnsupported, "line 1: anchors, aliases, tags and directives are not supported"},
+	{"multiple documents", "a: 1\n---\nb: 2\n", errYAMLMultipleDocs, "line 2: multiple documents are not supported"},
+	{"unterminated quote", "a: 1\nb: \"x\n", errYAMLUnterminated, "line 2: quoted string is not terminated"},
+	{"text after quote", "a: 'x' y\n", errYAMLTrailing, "line 1: unexpected characters after quoted string"},
+	{"invalid escape", "a: \"\\q\"\n", errInvalidEscape, "line 1: invalid escape sequence: \\q"},
+	{"unclosed flow sequence", "a: [1, 2\n", errYAMLFlow, "line 1: invalid flow collection"},
+	{"empty flow sequence item", "a: [x, , y]\n", errYAMLFlow, "line 1: invalid flow collection"},
+	{"flow sequence starting with a comma", "a: [, x]\n", errYAMLFlow, "line 1: invalid flow collection"},
+	{"empty flow mapping key", "a: {: v}\n", errYAMLFlow, "line 1: invalid flow collection"},
+	{"flow mapping with only a comma", "a: {,}\n", errYAMLFlow, "line 1: invalid flow collection"},
+	{"block scalar header", "a: |2\n  x\n", errYAMLBlockScalar, "line 1: invalid block scalar header: |2"},
+	{"duplicate flattened key", "db:\n  host: a\ndb_host: b\n", errDuplicateFlatKey, "more than one value has the key: DB_HOST"},
+}
+
+func Test_parseYAML_Errors(t *testing.T) {
+	for _, tt := range test_parseYAML_Errors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML(tt.input, nil)
+
+			if assert.NotNil(t, err) {
+				assert.Equal(t, tt.expectedMsg, err.Error())
+				assert.True(t, errors.Is(err, tt.expectedErr))
+			}
+		})
+	}
+}
+
+func Test_Parse_YAMLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte("db:\n  host: yaml-host\n  port: 5432\ntags:\n  - a\n  - b\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+	s := struct {
+		Host string   `phnenv:"DB_HOST"`
+		Port int      `phnenv:"DB_PORT"`
+		Tags []string `phnenv:"TAGS"`
+	}{}
+
+	err = Parse(&s, WithSources(Map("env", map[string]string{"DB_HOST": "env-host"}), YAMLFile(path)))
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "env-host", s.Host)
+		assert.Equal(t, 5432, s.Port)
+		assert.Equal(t, []string{"a", "b"}, s.Tags)
+	}
+}